@@ -2,38 +2,128 @@ package telegram
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
+	"sync"
 )
 
 // Bot is a Telegram bot.
 //
 // https://core.telegram.org/bots
 type Bot struct {
-	token   string
-	hostURL string
-	client  *http.Client
+	token        string
+	id           int
+	hostURL      string
+	client       *http.Client
+	lazy         bool
+	localMode    bool
+	retryPolicy  *RetryPolicy
+	rateLimiter  *rateLimiter
+	chatMigrator *chatMigrator
+	interceptors []Interceptor
+	dryRun       *dryRun
+	invoker      Invoker
 
-	User User // Bot info.
+	mu      sync.Mutex
+	hasUser bool
+
+	User User // Bot info, set by NewBot unless the bot is lazy. See Me.
 }
 
 // NewBot returns a new bot given a token and other optional options.
+// A malformed token is rejected without making any request.
+// Unless the SetLazy option is given, NewBot checks the token by calling GetMe.
 func NewBot(token string, options ...Option) (*Bot, error) {
-	bot := &Bot{token: token}
+	id, err := ParseToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	bot := &Bot{token: token, id: id}
 	for _, option := range options {
 		option(bot)
 	}
 
 	bot.trySetDefaultHostURL()
 	bot.trySetDefaultClient()
+	bot.invoker = bot.newInvoker()
 
-	_, err := bot.GetMe()
+	if bot.lazy {
+		return bot, nil
+	}
+
+	_, err = bot.GetMe()
 
 	return bot, err
 }
 
+// ParseToken returns the bot id of a token in the form <id>:<secret>,
+// or ErrInvalidToken if the token is malformed.
+func ParseToken(token string) (int, error) {
+	i := strings.IndexByte(token, ':')
+	if i < 0 {
+		return 0, ErrInvalidToken
+	}
+
+	id, secret := token[:i], token[i+1:]
+	botID, err := strconv.Atoi(id)
+	if err != nil || botID <= 0 || id[0] == '+' || secret == "" {
+		return 0, ErrInvalidToken
+	}
+	for _, r := range secret {
+		if !isTokenRune(r) {
+			return 0, ErrInvalidToken
+		}
+	}
+
+	return botID, nil
+}
+
+// isTokenRune reports whether r may be part of the secret of a token.
+func isTokenRune(r rune) bool {
+	return 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' || r == '_' || r == '-'
+}
+
+// ID returns the bot id, known from the token without making any request.
+func (bot *Bot) ID() int {
+	return bot.id
+}
+
+// Me returns the bot info. The info is fetched with GetMe on first use and cached,
+// so it's available even when the bot is created with SetLazy.
+func (bot *Bot) Me() (User, error) {
+	return bot.MeContext(context.Background())
+}
+
+// MeContext is like Me but with a context.
+func (bot *Bot) MeContext(ctx context.Context) (User, error) {
+	bot.mu.Lock()
+	if bot.hasUser {
+		defer bot.mu.Unlock()
+		return bot.User, nil
+	}
+	bot.mu.Unlock()
+
+	return bot.GetMeContext(ctx)
+}
+
+// setUser caches the bot info.
+func (bot *Bot) setUser(user User) {
+	bot.mu.Lock()
+	defer bot.mu.Unlock()
+
+	bot.User = user
+	bot.hasUser = true
+}
+
 // Response represents a general response from Telegram.
 //
 // https://core.telegram.org/bots/api#making-requests
@@ -44,7 +134,8 @@ type Response struct {
 	ErrorCode   int                 `json:"error_code,omitempty"`  // Optional.
 	Parameters  *ResponseParameters `json:"parameters,omitempty"`  // Optional.
 
-	buf *bytes.Reader
+	methodName string
+	buf        *bytes.Reader
 }
 
 func (r *Response) Read(b []byte) (n int, err error) {
@@ -54,9 +145,18 @@ func (r *Response) Read(b []byte) (n int, err error) {
 	return r.buf.Read(b)
 }
 
+// decode decodes the result into v, a failure is reported as DecodeError.
+func (r *Response) decode(v interface{}) error {
+	if err := json.NewDecoder(r).Decode(v); err != nil {
+		return &DecodeError{Method: r.methodName, Err: err}
+	}
+	return nil
+}
+
 // MakeRequest is a raw method to make a request to the Telegram API.
 // Generally other bot method like GetMe or GetUpdates is used instead
 // unless there's a new method in Telegram API that doesn't handle yet by the bot.
+// The params are sent in the request body as application/x-www-form-urlencoded.
 //
 // All request are passed to Telegram Bot API in the form:
 //  https://api.telegram.org/bot<token>/METHOD_NAME
@@ -65,41 +165,212 @@ func (r *Response) Read(b []byte) (n int, err error) {
 //
 // https://core.telegram.org/bots/api#making-requests
 func (bot *Bot) MakeRequest(methodName string, params url.Values) (*Response, error) {
+	return bot.MakeRequestContext(context.Background(), methodName, params)
+}
+
+// MakeRequestContext is like MakeRequest but with a context.
+// The context is passed to the HTTP request, so cancelling it aborts the request,
+// including a long polling GetUpdates.
+func (bot *Bot) MakeRequestContext(ctx context.Context, methodName string, params url.Values) (*Response, error) {
+	return bot.makeRequest(ctx, methodName, newParams(params))
+}
+
+// MakeRequestParams is like MakeRequest but with the params of a bot method rather than url.Values,
+// so a raw request may upload files and send JSON values. When a param carries a file to upload,
+// the params are sent as multipart/form-data.
+//
+//	setDocument := func(params *telegram.Params) {
+//		params.SetFile("document", telegram.InputFileLocal("report.pdf"))
+//	}
+//	resp, err := bot.MakeRequestParams("sendDocument", setDocument)
+func (bot *Bot) MakeRequestParams(methodName string, params ...Param) (*Response, error) {
+	return bot.MakeRequestParamsContext(context.Background(), methodName, params...)
+}
+
+// MakeRequestParamsContext is like MakeRequestParams but with a context.
+func (bot *Bot) MakeRequestParamsContext(ctx context.Context, methodName string, params ...Param) (*Response, error) {
+	return bot.makeRequest(ctx, methodName, resolveParam(params))
+}
+
+// Invoker makes a request to the Telegram API and returns its successful response,
+// or an error such as BotError when the request is unsuccessful.
+type Invoker func(ctx context.Context, methodName string, params *Params) (*Response, error)
+
+// Interceptor wraps an Invoker, it's called for every request made by the bot.
+// An Interceptor may look at or change the params before calling next, or add headers
+// to the HTTP request with Params.SetHeader,
+// and look at or replace the response and the error it returns.
+//
+//	func logRequest(next telegram.Invoker) telegram.Invoker {
+//		return func(ctx context.Context, methodName string, params *telegram.Params) (*telegram.Response, error) {
+//			start := time.Now()
+//			resp, err := next(ctx, methodName, params)
+//			log.Printf("%s %s: %v", methodName, time.Since(start), err)
+//			return resp, err
+//		}
+//	}
+type Interceptor func(next Invoker) Invoker
+
+// makeRequest makes a request with params, files in params are uploaded using multipart/form-data.
+func (bot *Bot) makeRequest(ctx context.Context, methodName string, params *Params) (*Response, error) {
+	return bot.invoker(ctx, methodName, params)
+}
+
+// newInvoker returns the Invoker of the bot. The interceptors are called in the order they're set,
+// around the handling of migrated chats, then the retry of failed requests and the rate limiting of each attempt.
+// A dry run bot writes the requests instead of sending them.
+func (bot *Bot) newInvoker() Invoker {
+	invoker := bot.send
+	if bot.dryRun != nil {
+		invoker = bot.dryRun.send
+	}
+	if bot.retryPolicy != nil {
+		invoker = bot.retryPolicy.wrap(invoker)
+	}
+	if bot.chatMigrator != nil {
+		invoker = bot.chatMigrator.wrap(invoker)
+	}
+	for i := len(bot.interceptors) - 1; i >= 0; i-- {
+		invoker = bot.interceptors[i](invoker)
+	}
+	return invoker
+}
+
+// send sends a single request to the Telegram API, waiting for the rate limiter if any.
+func (bot *Bot) send(ctx context.Context, methodName string, params *Params) (*Response, error) {
+	if bot.rateLimiter != nil {
+		if err := bot.rateLimiter.wait(ctx, methodName, params); err != nil {
+			return nil, err
+		}
+	}
+
+	if bot.localMode {
+		var err error
+		if params, err = params.withLocalFiles(); err != nil {
+			return nil, err
+		}
+	} else if err := params.checkUploadSize(MaxUploadSize); err != nil {
+		return nil, err
+	}
+
 	endpoint := fmt.Sprintf("%s/bot%s/%s", bot.hostURL, bot.token, methodName)
 
-	req, err := http.NewRequest(http.MethodPost, endpoint, nil)
+	req, err := newHTTPRequest(ctx, endpoint, params)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	if params != nil {
-		req.URL.RawQuery = params.Encode()
-	}
 
 	w, err := bot.client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, bot.networkError(methodName, err)
 	}
 	defer w.Body.Close()
 
-	var resp Response
+	resp := Response{methodName: methodName}
 	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
-		return nil, err
+		if w.StatusCode >= http.StatusInternalServerError {
+			// a proxy in front of the API may answer with a non JSON body.
+			return nil, &BotError{Code: w.StatusCode, Description: http.StatusText(w.StatusCode)}
+		}
+		return nil, &DecodeError{Method: methodName, Err: err}
 	}
 
 	if !resp.OK {
-		return nil, &BotError{Code: resp.ErrorCode, Description: resp.Description}
+		return nil, newBotError(resp)
 	}
 
 	return &resp, nil
 }
 
-// BotError records an error code (http status code) and the description.
-type BotError struct {
-	Code        int
-	Description string
+// networkError returns a NetworkError of the failed request, keeping the token out of the error message.
+func (bot *Bot) networkError(methodName string, err error) error {
+	var urlError *url.Error
+	if errors.As(err, &urlError) {
+		urlError.URL = strings.Replace(urlError.URL, bot.token, "<token>", 1)
+	}
+	return &NetworkError{Method: methodName, Err: err}
+}
+
+// newHTTPRequest returns an http request to the endpoint with params in its body.
+// When there's a file to upload, the body is streamed as multipart/form-data.
+// Otherwise params with a JSON value are sent as a JSON object
+// and the plain url.Values as application/x-www-form-urlencoded.
+func newHTTPRequest(ctx context.Context, endpoint string, params *Params) (*http.Request, error) {
+	if !params.hasUpload() {
+		contentType := "application/x-www-form-urlencoded"
+		body := []byte(params.Encode())
+		if params.hasJSON() {
+			var err error
+			if body, err = params.encodeJSON(); err != nil {
+				return nil, err
+			}
+			contentType = "application/json"
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		setHeader(req, params)
+		req.Header.Set("Content-Type", contentType)
+		return req, nil
+	}
+
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, pr)
+	if err != nil {
+		return nil, err
+	}
+	setHeader(req, params)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	go func() {
+		pw.CloseWithError(writeMultipart(mw, params))
+	}()
+
+	return req, nil
+}
+
+// setHeader adds the headers set with Params.SetHeader to the request.
+func setHeader(req *http.Request, params *Params) {
+	for key, values := range params.header {
+		req.Header[key] = values
+	}
 }
 
-func (e *BotError) Error() string {
-	return fmt.Sprintf("%d %s", e.Code, e.Description)
+// writeMultipart writes params as multipart/form-data to mw, closing mw when done.
+func writeMultipart(mw *multipart.Writer, params *Params) error {
+	for field, values := range params.Values {
+		for _, value := range values {
+			if err := mw.WriteField(field, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	for field, file := range params.files {
+		if err := writeMultipartFile(mw, field, file); err != nil {
+			return err
+		}
+	}
+
+	return mw.Close()
+}
+
+func writeMultipartFile(mw *multipart.Writer, field string, file InputFile) error {
+	r, err := file.open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	part, err := mw.CreateFormFile(field, file.name)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(part, r)
+	return err
 }
@@ -1,10 +1,21 @@
 package telegram_test
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/62bot/telegram"
 	"github.com/billyzaelani/is"
@@ -49,13 +60,49 @@ func TestMethod(t *testing.T) {
 		"getUpdates/with_params":           getUpdatesWithParams,
 		"getUpdates/conflict_with_webhook": getUpdatesConflictWithWebhook,
 		"setWebhook/ok":                    setWebhookOK,
+		"setWebhook/with_certificate":      setWebhookWithCertificate,
 		"setWebhook/with_params":           setWebhookWithParams,
+		"setWebhook/with_secret_token":     setWebhookWithSecretToken,
 		"setWebhook/error_not_https":       setWebhookErrorNotHTTPS,
 		"deleteWebhook/ok":                 deleteWebhookOK,
 		"getWebhookInfo/ok":                getWebhookInfoOK,
 
 		// methods_test
-		"sendMessage/ok": sendMessageOK,
+		"sendMessage/ok":                sendMessageOK,
+		"sendPhoto/ok":                  sendPhotoOK,
+		"sendPhoto/upload":              sendPhotoUpload,
+		"sendPhoto/error_wrong_file_id": sendPhotoErrorWrongFileID,
+		"sendAudio/ok":                  sendAudioOK,
+		"sendDocument/with_thumb":       sendDocumentWithThumb,
+		"sendVideo/ok":                  sendVideoOK,
+		"sendAnimation/ok":              sendAnimationOK,
+		"sendVoice/ok":                  sendVoiceOK,
+		"sendVideoNote/ok":              sendVideoNoteOK,
+		"sendMediaGroup/ok":             sendMediaGroupOK,
+		"editMessageText/ok":            editMessageTextOK,
+		"editMessageText/inline":        editMessageTextInline,
+		"editMessageText/not_modified":  editMessageTextNotModified,
+		"editMessageCaption/ok":         editMessageCaptionOK,
+		"editMessageMedia/ok":           editMessageMediaOK,
+		"editMessageReplyMarkup/remove": editMessageReplyMarkupRemove,
+		"forwardMessage/ok":             forwardMessageOK,
+		"copyMessage/ok":                copyMessageOK,
+		"deleteMessage/ok":              deleteMessageOK,
+		"deleteMessage/not_found":       deleteMessageNotFound,
+		"getFile/ok":                    getFileOK,
+		"logOut/ok":                     logOutOK,
+		"close/ok":                      closeOK,
+		"close/too_early":               closeTooEarly,
+
+		// chat_test
+		"banChatMember/ok":                   banChatMemberOK,
+		"banChatMember/not_enough_rights":    banChatMemberNotEnoughRights,
+		"kickChatMember/ok":                  kickChatMemberOK,
+		"unbanChatMember/only_if_banned":     unbanChatMemberOnlyIfBanned,
+		"restrictChatMember/ok":              restrictChatMemberOK,
+		"promoteChatMember/ok":               promoteChatMemberOK,
+		"setChatAdministratorCustomTitle/ok": setChatAdministratorCustomTitleOK,
+		"setChatPermissions/ok":              setChatPermissionsOK,
 	}
 
 	for name, f := range tests {
@@ -91,3 +138,737 @@ func TestMethod(t *testing.T) {
 		})
 	}
 }
+
+// TestMakeRequestContext tests that the request context reaches the HTTP transport.
+func TestMakeRequestContext(t *testing.T) {
+	is := is.New(t)
+
+	client := newTestClient(func(methodName string, params url.Values) *http.Response {
+		return newHTTPResponse(authorizedCase.StatusCode, authorizedCase.Body)
+	})
+	bot, err := telegram.NewBot(validTestToken, telegram.SetClient(client))
+	is.NoError(err)
+
+	// replace the client after NewBot so getMe is not blocked.
+	blocked := make(chan struct{})
+	*client = http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		close(blocked)
+		<-r.Context().Done()
+		return nil, r.Context().Err()
+	})}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-blocked
+		cancel()
+	}()
+
+	_, err = bot.GetUpdatesContext(ctx, telegram.SetTimeout(50))
+	is.Error(err, context.Canceled)
+}
+
+type roundTripperFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// TestMakeRequestUpload tests that params with a file to upload are sent as multipart/form-data.
+func TestMakeRequestUpload(t *testing.T) {
+	is := is.New(t)
+
+	testCases := testFixture.get("sendMessage")
+	tc := testCases.get("ok")
+
+	var gotParams url.Values
+	client := newTestClient(func(methodName string, params url.Values) *http.Response {
+		gotParams = params
+		return newHTTPResponse(tc.StatusCode, tc.Body)
+	})
+	bot, err := telegram.NewBot(validTestToken, telegram.SetClient(client))
+	is.NoError(err)
+
+	setDocument := func(params *telegram.Params) {
+		params.SetFile("document", telegram.InputFileReader("note.txt", strings.NewReader("hello")))
+	}
+	setThumb := func(params *telegram.Params) {
+		params.SetFile("thumb", telegram.InputFileID("file-id"))
+	}
+
+	_, err = bot.SendMessage(12345, "hi", setDocument, setThumb)
+	is.NoError(err)
+
+	is.Equal(gotParams.Get("chat_id"), "12345")
+	is.Equal(gotParams.Get("text"), "hi")
+	is.Equal(gotParams.Get("document"), "note.txt:hello")
+	is.Equal(gotParams.Get("thumb"), "file-id") // file id is sent as a regular value
+
+	setChatID := func(params *telegram.Params) {
+		params.Set("chat_id", "12345")
+	}
+	resp, err := bot.MakeRequestParams("sendDocument", setChatID, setDocument)
+	is.NoError(err)
+	is.True(resp.OK)
+
+	is.Equal(gotParams.Get("chat_id"), "12345")
+	is.Equal(gotParams.Get("document"), "note.txt:hello") // a raw request uploads files too
+
+	setParseMode := func(v url.Values) {
+		v.Set("parse_mode", "HTML")
+	}
+	_, err = bot.SendMessage(12345, "hi", telegram.ParamValues(setParseMode))
+	is.NoError(err)
+	is.Equal(gotParams.Get("parse_mode"), "HTML") // a func(url.Values) is still a parameter
+
+	_, err = bot.MakeRequestParams("sendMessage", telegram.SetChatID(12345), telegram.ParamValues(setParseMode))
+	is.NoError(err)
+	is.Equal(gotParams.Get("parse_mode"), "HTML")
+}
+
+// TestRetryPolicy tests the retry of failed requests.
+func TestRetryPolicy(t *testing.T) {
+	var (
+		tooManyRequests = []byte(`{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 0","parameters":{"retry_after":0}}`)
+		badGateway      = []byte(`<html>502 Bad Gateway</html>`)
+		badRequest      = []byte(`{"ok":false,"error_code":400,"description":"Bad Request: chat not found"}`)
+		webhookInfo     = []byte(`{"ok":true,"result":{"url":"https://example.com","has_custom_certificate":false,"pending_update_count":0}}`)
+		sentMessage     = []byte(`{"ok":true,"result":{"message_id":1,"date":0,"chat":{"id":1,"type":"private"}}}`)
+	)
+
+	policy := telegram.RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+	tests := map[string]struct {
+		policy       telegram.RetryPolicy
+		call         func(bot *telegram.Bot) error
+		responses    []*http.Response
+		wantAttempts int
+		wantCode     int
+	}{
+		"flood_wait": {
+			policy: policy,
+			call: func(bot *telegram.Bot) error {
+				_, err := bot.GetWebhookInfo()
+				return err
+			},
+			responses:    []*http.Response{newHTTPResponse(http.StatusTooManyRequests, tooManyRequests), newHTTPResponse(http.StatusOK, webhookInfo)},
+			wantAttempts: 2,
+		},
+		"server_error": {
+			policy: policy,
+			call: func(bot *telegram.Bot) error {
+				_, err := bot.GetWebhookInfo()
+				return err
+			},
+			responses:    []*http.Response{newHTTPResponse(http.StatusBadGateway, badGateway), newHTTPResponse(http.StatusBadGateway, badGateway), newHTTPResponse(http.StatusBadGateway, badGateway)},
+			wantAttempts: 3,
+			wantCode:     http.StatusBadGateway,
+		},
+		"client_error": {
+			policy: policy,
+			call: func(bot *telegram.Bot) error {
+				_, err := bot.GetWebhookInfo()
+				return err
+			},
+			responses:    []*http.Response{newHTTPResponse(http.StatusBadRequest, badRequest)},
+			wantAttempts: 1,
+			wantCode:     http.StatusBadRequest,
+		},
+		"non_idempotent": {
+			policy: policy,
+			call: func(bot *telegram.Bot) error {
+				_, err := bot.SendMessage(1, "hi")
+				return err
+			},
+			responses:    []*http.Response{newHTTPResponse(http.StatusTooManyRequests, tooManyRequests)},
+			wantAttempts: 1,
+			wantCode:     http.StatusTooManyRequests,
+		},
+		"non_idempotent_opt_in": {
+			policy: telegram.RetryPolicy{MaxAttempts: 3, RetryNonIdempotent: true},
+			call: func(bot *telegram.Bot) error {
+				_, err := bot.SendMessage(1, "hi")
+				return err
+			},
+			responses:    []*http.Response{newHTTPResponse(http.StatusTooManyRequests, tooManyRequests), newHTTPResponse(http.StatusOK, sentMessage)},
+			wantAttempts: 2,
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			is := is.New(t)
+
+			attempts := 0
+			client := newTestClient(func(methodName string, params url.Values) *http.Response {
+				attempts++
+				return tt.responses[attempts-1]
+			})
+			bot, err := telegram.NewBot(validTestToken, telegram.SetClient(client), telegram.SetRetryPolicy(tt.policy))
+			is.NoError(err)
+
+			err = tt.call(bot)
+			is.Equal(attempts, tt.wantAttempts)
+			if tt.wantCode == 0 {
+				is.NoError(err)
+				return
+			}
+
+			var botError *telegram.BotError
+			is.ErrorAs(err, &botError)
+			is.Equal(botError.Code, tt.wantCode)
+		})
+	}
+}
+
+// TestBotErrorParameters tests that the response parameters are available from BotError.
+func TestBotErrorParameters(t *testing.T) {
+	is := is.New(t)
+
+	client := newTestClient(func(methodName string, params url.Values) *http.Response {
+		return newHTTPResponse(http.StatusBadRequest, []byte(`{"ok":false,"error_code":400,"description":"Bad Request: group chat was upgraded to a supergroup chat","parameters":{"migrate_to_chat_id":-1001234}}`))
+	})
+	bot, err := telegram.NewBot(validTestToken, telegram.SetClient(client))
+	is.NoError(err)
+
+	_, err = bot.SendMessage(-1234, "hi")
+	var botError *telegram.BotError
+	is.ErrorAs(err, &botError)
+	is.True(botError.Parameters != nil)
+	is.Equal(botError.Parameters.MigrateToChatID, -1001234)
+	is.Equal(botError.RetryAfter(), time.Duration(0)) // not told to wait
+
+	botError = &telegram.BotError{Code: http.StatusTooManyRequests, Parameters: &telegram.ResponseParameters{RetryAfter: 5}}
+	is.Equal(botError.RetryAfter(), 5*time.Second)
+}
+
+// TestRateLimits tests that requests to a chat are delayed to keep them within the limits.
+func TestRateLimits(t *testing.T) {
+	is := is.New(t)
+
+	testCases := testFixture.get("sendMessage")
+	tc := testCases.get("ok")
+
+	var (
+		mu    sync.Mutex
+		sent  = make(map[string][]time.Time)
+		start = time.Now()
+	)
+	client := newTestClient(func(methodName string, params url.Values) *http.Response {
+		mu.Lock()
+		defer mu.Unlock()
+		chatID := params.Get("chat_id")
+		sent[chatID] = append(sent[chatID], time.Now())
+		return newHTTPResponse(tc.StatusCode, tc.Body)
+	})
+
+	limits := telegram.RateLimits{
+		PrivateChat: telegram.RateLimit{Count: 1, Per: 100 * time.Millisecond},
+		GroupChat:   telegram.RateLimit{Count: 2, Per: 200 * time.Millisecond},
+	}
+	bot, err := telegram.NewBot(validTestToken, telegram.SetClient(client), telegram.SetRateLimits(limits))
+	is.NoError(err)
+
+	chatIDs := []int{1, 1, 1, 2, -3, -3, -3}
+	errs := make(chan error, len(chatIDs))
+	for _, chatID := range chatIDs {
+		go func(chatID int) {
+			_, err := bot.SendMessage(chatID, "hi")
+			errs <- err
+		}(chatID)
+	}
+	for range chatIDs {
+		is.NoError(<-errs)
+	}
+
+	is.True(sent["1"][2].Sub(start) >= 200*time.Millisecond)  // third message to a private chat waits for two periods
+	is.True(sent["2"][0].Sub(start) < 100*time.Millisecond)   // other chats are not delayed
+	is.True(sent["-3"][1].Sub(start) < 100*time.Millisecond)  // groups allow a burst
+	is.True(sent["-3"][2].Sub(start) >= 100*time.Millisecond) // group burst is followed by one message every period/count
+}
+
+// TestRateLimitsFairness tests that a backlog of one chat delays neither the other chats nor the methods not limited.
+func TestRateLimitsFairness(t *testing.T) {
+	is := is.New(t)
+
+	testCases := testFixture.get("sendMessage")
+	tc := testCases.get("ok")
+
+	var (
+		mu    sync.Mutex
+		sent  = make(map[string][]time.Time)
+		start = time.Now()
+	)
+	client := newTestClient(func(methodName string, params url.Values) *http.Response {
+		mu.Lock()
+		defer mu.Unlock()
+		key := methodName + ":" + params.Get("chat_id")
+		sent[key] = append(sent[key], time.Now())
+		if methodName == "deleteMessage" {
+			return newHTTPResponse(http.StatusOK, []byte(`{"ok":true,"result":true}`))
+		}
+		return newHTTPResponse(tc.StatusCode, tc.Body)
+	})
+
+	limits := telegram.RateLimits{
+		Global:      telegram.RateLimit{Count: 3, Per: 300 * time.Millisecond},
+		PrivateChat: telegram.RateLimit{Count: 1, Per: 100 * time.Millisecond},
+	}
+	bot, err := telegram.NewBot(validTestToken, telegram.SetClient(client), telegram.SetRateLimits(limits))
+	is.NoError(err)
+
+	const backlog = 5
+	errs := make(chan error, backlog)
+	for i := 0; i < backlog; i++ {
+		go func() {
+			_, err := bot.SendMessage(111, "hi")
+			errs <- err
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	_, err = bot.SendMessage(222, "hi")
+	is.NoError(err)
+	_, err = bot.DeleteMessage(111, 1)
+	is.NoError(err)
+	for i := 0; i < backlog; i++ {
+		is.NoError(<-errs)
+	}
+
+	is.True(sent["sendMessage:222"][0].Sub(start) < 100*time.Millisecond)   // a fresh chat isn't delayed by the backlog of another chat
+	is.True(sent["deleteMessage:111"][0].Sub(start) < 100*time.Millisecond) // methods not sending a message aren't limited
+	is.True(sent["sendMessage:111"][4].Sub(start) >= 400*time.Millisecond)  // the backlog is still sent one message every period
+}
+
+// TestMakeRequestBody tests that the params are sent in the request body rather than in the URL.
+func TestMakeRequestBody(t *testing.T) {
+	is := is.New(t)
+
+	testCases := testFixture.get("sendMessage")
+	tc := testCases.get("ok")
+
+	var (
+		gotQuery       string
+		gotContentType string
+		gotBody        []byte
+	)
+	client := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		if path.Base(r.URL.Path) == "getMe" {
+			return newHTTPResponse(authorizedCase.StatusCode, authorizedCase.Body), nil
+		}
+
+		gotQuery = r.URL.RawQuery
+		gotContentType = r.Header.Get("Content-Type")
+		gotBody, _ = ioutil.ReadAll(r.Body)
+		return newHTTPResponse(tc.StatusCode, tc.Body), nil
+	})}
+	bot, err := telegram.NewBot(validTestToken, telegram.SetClient(client))
+	is.NoError(err)
+
+	_, err = bot.SendMessage(12345, "hello world")
+	is.NoError(err)
+
+	is.Equal(gotQuery, "")
+	is.Equal(gotContentType, "application/json")
+	is.Equal(string(gotBody), `{"chat_id":12345,"text":"hello world"}`)
+
+	_, err = bot.MakeRequest("sendMessage", url.Values{"chat_id": {"12345"}, "text": {"hello world"}})
+	is.NoError(err)
+
+	is.Equal(gotQuery, "")
+	is.Equal(gotContentType, "application/x-www-form-urlencoded")
+	is.Equal(string(gotBody), "chat_id=12345&text=hello+world")
+}
+
+// TestErrorKinds tests the classification of failed requests.
+func TestErrorKinds(t *testing.T) {
+	tests := map[string]struct {
+		response *http.Response
+		err      error
+		want     error
+	}{
+		"unauthorized":   {response: newHTTPResponse(http.StatusUnauthorized, []byte(`{"ok":false,"error_code":401,"description":"Unauthorized"}`)), want: telegram.ErrUnauthorized},
+		"forbidden":      {response: newHTTPResponse(http.StatusForbidden, []byte(`{"ok":false,"error_code":403,"description":"Forbidden: bot is not a member of the channel chat"}`)), want: telegram.ErrForbidden},
+		"bot_blocked":    {response: newHTTPResponse(http.StatusForbidden, []byte(`{"ok":false,"error_code":403,"description":"Forbidden: bot was blocked by the user"}`)), want: telegram.ErrBotBlocked},
+		"chat_not_found": {response: newHTTPResponse(http.StatusBadRequest, []byte(`{"ok":false,"error_code":400,"description":"Bad Request: chat not found"}`)), want: telegram.ErrChatNotFound},
+		"not_modified":   {response: newHTTPResponse(http.StatusBadRequest, []byte(`{"ok":false,"error_code":400,"description":"Bad Request: message is not modified: specified new message content and reply markup are exactly the same as a current content and reply markup of the message"}`)), want: telegram.ErrMessageNotModified},
+		"flood_wait":     {response: newHTTPResponse(http.StatusTooManyRequests, []byte(`{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 5","parameters":{"retry_after":5}}`)), want: telegram.ErrTooManyRequests},
+		"chat_migrated":  {response: newHTTPResponse(http.StatusBadRequest, []byte(`{"ok":false,"error_code":400,"description":"Bad Request: group chat was upgraded to a supergroup chat","parameters":{"migrate_to_chat_id":-1001234}}`)), want: telegram.ErrChatMigrated},
+		"network":        {err: errors.New("connection reset by peer")},
+		"decode":         {response: newHTTPResponse(http.StatusOK, []byte(`not a json`))},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			is := is.New(t)
+
+			client := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				if path.Base(r.URL.Path) == "getMe" {
+					return newHTTPResponse(authorizedCase.StatusCode, authorizedCase.Body), nil
+				}
+				return tt.response, tt.err
+			})}
+			bot, err := telegram.NewBot(validTestToken, telegram.SetClient(client))
+			is.NoError(err)
+
+			_, err = bot.SendMessage(-1234, "hi")
+			switch name {
+			case "network":
+				var networkError *telegram.NetworkError
+				is.ErrorAs(err, &networkError)
+				is.Equal(networkError.Method, "sendMessage")
+			case "decode":
+				var decodeError *telegram.DecodeError
+				is.ErrorAs(err, &decodeError)
+			default:
+				is.Error(err, tt.want)
+				var botError *telegram.BotError
+				is.ErrorAs(err, &botError) // every API error is a BotError
+			}
+		})
+	}
+
+	is := is.New(t)
+	err := error(&telegram.BotError{Code: http.StatusBadRequest, Description: "Bad Request: chat not found"})
+	is.True(!errors.Is(err, telegram.ErrBotBlocked))
+	is.True(!errors.Is(err, telegram.ErrMessageNotModified))
+
+	var migrated *telegram.ChatMigratedError
+	err = &telegram.ChatMigratedError{MigrateToChatID: -1001234, Err: &telegram.BotError{Code: http.StatusBadRequest}}
+	is.ErrorAs(err, &migrated)
+	is.Equal(migrated.MigrateToChatID, -1001234)
+}
+
+// TestChatMigration tests that requests to a migrated chat are sent to the supergroup.
+func TestChatMigration(t *testing.T) {
+	is := is.New(t)
+
+	var (
+		oldChatID = -1234
+		newChatID = -1001234
+		migrated  = []byte(`{"ok":false,"error_code":400,"description":"Bad Request: group chat was upgraded to a supergroup chat","parameters":{"migrate_to_chat_id":-1001234}}`)
+		sent      = []byte(`{"ok":true,"result":{"message_id":1,"date":0,"chat":{"id":-1001234,"type":"supergroup"}}}`)
+	)
+
+	var gotChatIDs []string
+	client := newTestClient(func(methodName string, params url.Values) *http.Response {
+		chatID := params.Get("chat_id")
+		gotChatIDs = append(gotChatIDs, chatID)
+		if chatID == strconv.Itoa(oldChatID) {
+			return newHTTPResponse(http.StatusBadRequest, migrated)
+		}
+		return newHTTPResponse(http.StatusOK, sent)
+	})
+
+	var migrations [][2]int
+	onMigrate := func(oldChatID, newChatID int) {
+		migrations = append(migrations, [2]int{oldChatID, newChatID})
+	}
+	bot, err := telegram.NewBot(validTestToken, telegram.SetClient(client), telegram.SetChatMigration(onMigrate))
+	is.NoError(err)
+
+	message, err := bot.SendMessage(oldChatID, "hi")
+	is.NoError(err)
+	is.Equal(message.Chat.ID, newChatID)
+
+	_, err = bot.SendMessage(oldChatID, "hi again")
+	is.NoError(err)
+
+	is.Equal(gotChatIDs, []string{"-1234", "-1001234", "-1001234"}) // only the first request is sent to the old chat
+	is.Equal(migrations, [][2]int{{oldChatID, newChatID}})          // hook is called once
+
+	// an upload from a reader isn't sent again, but the migration is remembered.
+	const readerChatID = -5678
+	migrated = []byte(`{"ok":false,"error_code":400,"description":"Bad Request: group chat was upgraded to a supergroup chat","parameters":{"migrate_to_chat_id":-1005678}}`)
+	oldChatID = readerChatID
+	gotChatIDs = nil
+
+	_, err = bot.SendDocument(readerChatID, telegram.InputFileReader("note.txt", strings.NewReader("hello")))
+	is.ErrorAs(err, new(*telegram.ChatMigratedError))
+
+	_, err = bot.SendMessage(readerChatID, "hi")
+	is.NoError(err)
+
+	is.Equal(gotChatIDs, []string{"-5678", "-1005678"})
+	is.Equal(migrations, [][2]int{{-1234, newChatID}, {readerChatID, -1005678}})
+}
+
+// TestChatMigrationForward tests that a migrated source chat of a forward or a copy isn't taken for the target chat.
+func TestChatMigrationForward(t *testing.T) {
+	is := is.New(t)
+
+	var (
+		migrated = []byte(`{"ok":false,"error_code":400,"description":"Bad Request: group chat was upgraded to a supergroup chat","parameters":{"migrate_to_chat_id":-1001234}}`)
+		sent     = []byte(`{"ok":true,"result":{"message_id":1,"date":0,"chat":{"id":555,"type":"private"}}}`)
+		copied   = []byte(`{"ok":true,"result":{"message_id":1}}`)
+	)
+
+	var gotRequests []string
+	client := newTestClient(func(methodName string, params url.Values) *http.Response {
+		gotRequests = append(gotRequests, methodName+" "+params.Get("chat_id")+" "+params.Get("from_chat_id"))
+		if params.Get("chat_id") == "-1234" || params.Get("from_chat_id") == "-1234" {
+			return newHTTPResponse(http.StatusBadRequest, migrated)
+		}
+		if methodName == "copyMessage" {
+			return newHTTPResponse(http.StatusOK, copied)
+		}
+		return newHTTPResponse(http.StatusOK, sent)
+	})
+
+	var migrations [][2]int
+	onMigrate := func(oldChatID, newChatID int) {
+		migrations = append(migrations, [2]int{oldChatID, newChatID})
+	}
+	bot, err := telegram.NewBot(validTestToken, telegram.SetClient(client), telegram.SetChatMigration(onMigrate))
+	is.NoError(err)
+
+	_, err = bot.ForwardMessage(555, -1234, 7)
+	is.ErrorAs(err, new(*telegram.ChatMigratedError))
+	_, err = bot.CopyMessage(555, -1234, 7)
+	is.ErrorAs(err, new(*telegram.ChatMigratedError))
+
+	_, err = bot.SendMessage(555, "hi")
+	is.NoError(err)
+	is.Equal(len(migrations), 0) // the target chat isn't taken for migrated
+
+	_, err = bot.SendMessage(-1234, "hi")
+	is.NoError(err)
+
+	_, err = bot.ForwardMessage(555, -1234, 7)
+	is.NoError(err)
+	_, err = bot.CopyMessage(555, -1234, 7)
+	is.NoError(err)
+
+	is.Equal(gotRequests, []string{
+		"forwardMessage 555 -1234",
+		"copyMessage 555 -1234",
+		"sendMessage 555 ",
+		"sendMessage -1234 ", "sendMessage -1001234 ",
+		"forwardMessage 555 -1001234", // known migration of the source chat
+		"copyMessage 555 -1001234",
+	})
+	is.Equal(migrations, [][2]int{{-1234, -1001234}})
+}
+
+// TestInterceptors tests that interceptors are called around every request in order.
+func TestInterceptors(t *testing.T) {
+	is := is.New(t)
+
+	testCases := testFixture.get("sendMessage")
+	tc := testCases.get("ok")
+
+	var gotParams url.Values
+	client := newTestClient(func(methodName string, params url.Values) *http.Response {
+		gotParams = params
+		return newHTTPResponse(tc.StatusCode, tc.Body)
+	})
+
+	var calls []string
+	record := func(name string) telegram.Interceptor {
+		return func(next telegram.Invoker) telegram.Invoker {
+			return func(ctx context.Context, methodName string, params *telegram.Params) (*telegram.Response, error) {
+				calls = append(calls, name+" "+methodName)
+				return next(ctx, methodName, params)
+			}
+		}
+	}
+	silent := func(next telegram.Invoker) telegram.Invoker {
+		return func(ctx context.Context, methodName string, params *telegram.Params) (*telegram.Response, error) {
+			params.Set("disable_notification", "true")
+			return next(ctx, methodName, params)
+		}
+	}
+
+	bot, err := telegram.NewBot(validTestToken,
+		telegram.SetClient(client),
+		telegram.SetInterceptors(record("first"), silent),
+		telegram.SetInterceptors(record("second")),
+	)
+	is.NoError(err)
+
+	_, err = bot.SendMessage(12345, "hi")
+	is.NoError(err)
+	is.Equal(gotParams.Get("disable_notification"), "true") // interceptor changed the params
+
+	_, err = bot.MakeRequest("sendMessage", url.Values{"chat_id": {"12345"}, "text": {"hi"}})
+	is.NoError(err)
+
+	is.Equal(calls, []string{
+		"first getMe", "second getMe",
+		"first sendMessage", "second sendMessage",
+		"first sendMessage", "second sendMessage",
+	})
+}
+
+// TestInterceptorHeader tests that the headers set by an interceptor are sent with the HTTP request.
+func TestInterceptorHeader(t *testing.T) {
+	is := is.New(t)
+
+	testCases := testFixture.get("sendMessage")
+	tc := testCases.get("ok")
+
+	gotHeaders := make(map[string]http.Header)
+	client := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		methodName := path.Base(r.URL.Path)
+		gotHeaders[methodName] = r.Header
+		if methodName == "getMe" {
+			return newHTTPResponse(authorizedCase.StatusCode, authorizedCase.Body), nil
+		}
+		return newHTTPResponse(tc.StatusCode, tc.Body), nil
+	})}
+
+	trace := func(next telegram.Invoker) telegram.Invoker {
+		return func(ctx context.Context, methodName string, params *telegram.Params) (*telegram.Response, error) {
+			params.SetHeader("Traceparent", "00-trace-"+methodName+"-01")
+			params.SetHeader("Content-Type", "text/plain") // the request sets its own Content-Type
+			return next(ctx, methodName, params)
+		}
+	}
+
+	bot, err := telegram.NewBot(validTestToken, telegram.SetClient(client), telegram.SetInterceptors(trace))
+	is.NoError(err)
+
+	_, err = bot.SendMessage(12345, "hi")
+	is.NoError(err)
+
+	is.Equal(gotHeaders["getMe"].Get("Traceparent"), "00-trace-getMe-01")
+	is.Equal(gotHeaders["sendMessage"].Get("Traceparent"), "00-trace-sendMessage-01")
+	is.Equal(gotHeaders["sendMessage"].Get("Content-Type"), "application/json")
+}
+
+// TestNetworkErrorRedactsToken tests that the token doesn't leak in the error message.
+func TestNetworkErrorRedactsToken(t *testing.T) {
+	is := is.New(t)
+
+	client := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		if path.Base(r.URL.Path) == "getMe" {
+			return newHTTPResponse(authorizedCase.StatusCode, authorizedCase.Body), nil
+		}
+		return nil, errors.New("connection refused")
+	})}
+	bot, err := telegram.NewBot(validTestToken, telegram.SetClient(client))
+	is.NoError(err)
+
+	_, err = bot.GetWebhookInfo()
+	is.Error(err)
+	is.True(!strings.Contains(err.Error(), validTestToken))
+}
+
+// TestNewBotLazy tests creation of a bot without any request.
+func TestNewBotLazy(t *testing.T) {
+	is := is.New(t)
+
+	var requests []string
+	client := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		requests = append(requests, path.Base(r.URL.Path))
+		return newHTTPResponse(authorizedCase.StatusCode, authorizedCase.Body), nil
+	})}
+
+	_, err := telegram.NewBot("not a token", telegram.SetClient(client))
+	is.Error(err, telegram.ErrInvalidToken)
+	is.Equal(len(requests), 0) // malformed token is rejected locally
+
+	bot, err := telegram.NewBot(validTestToken, telegram.SetClient(client), telegram.SetLazy())
+	is.NoError(err)
+	is.Equal(len(requests), 0) // lazy bot doesn't call getMe
+	is.Equal(bot.ID(), 123456)
+
+	var wantUser telegram.User
+	authorizedCase.decodeResponse(&wantUser)
+
+	for i := 0; i < 2; i++ {
+		user, err := bot.Me()
+		is.NoError(err)
+		is.Equal(user, wantUser)
+	}
+	is.Equal(requests, []string{"getMe"}) // bot info is cached
+	is.Equal(bot.User, wantUser)
+}
+
+// TestParseToken tests parsing of the bot id from a token.
+func TestParseToken(t *testing.T) {
+	is := is.New(t)
+
+	id, err := telegram.ParseToken("123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11")
+	is.NoError(err)
+	is.Equal(id, 123456)
+
+	for _, token := range []string{"", "123456", "123456:", ":secret", "abc:secret", "-1:secret", "+1:secret", "0:secret", "123456:sec ret", "123456:secret/../getMe"} {
+		_, err := telegram.ParseToken(token)
+		is.Error(err, telegram.ErrInvalidToken) // token is malformed
+	}
+}
+
+// TestLocalMode tests the differences of a bot using a local Bot API server.
+func TestLocalMode(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := ioutil.TempDir("", "telegram")
+	is.NoError(err)
+	defer os.RemoveAll(dir)
+
+	localFile := filepath.Join(dir, "document.txt")
+	is.NoError(ioutil.WriteFile(localFile, []byte("local content"), 0600))
+
+	testCases := testFixture.get("sendMessage")
+	tc := testCases.get("ok")
+
+	var (
+		gotParams url.Values
+		gotPath   string
+	)
+	client := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		gotPath = r.URL.Path
+		switch path.Base(r.URL.Path) {
+		case "getMe":
+			return newHTTPResponse(authorizedCase.StatusCode, authorizedCase.Body), nil
+		case "sendMessage":
+			gotParams = requestParams(r)
+			return newHTTPResponse(tc.StatusCode, tc.Body), nil
+		default:
+			return newHTTPResponse(http.StatusOK, []byte("remote content")), nil
+		}
+	})}
+
+	setDocument := func(params *telegram.Params) {
+		params.SetFile("document", telegram.InputFileLocal(localFile))
+	}
+	bigDocument := func(params *telegram.Params) {
+		params.SetFile("document", telegram.InputFileReader("big.bin", bytes.NewReader(make([]byte, telegram.MaxUploadSize+1))))
+	}
+	read := func(rc io.ReadCloser, err error) string {
+		is.NoError(err)
+		defer rc.Close()
+		b, err := ioutil.ReadAll(rc)
+		is.NoError(err)
+		return string(b)
+	}
+
+	// cloud mode
+	bot, err := telegram.NewBot(validTestToken, telegram.SetClient(client))
+	is.NoError(err)
+
+	_, err = bot.SendMessage(12345, "hi", setDocument)
+	is.NoError(err)
+	is.Equal(gotParams.Get("document"), "document.txt:local content") // local file is uploaded
+
+	_, err = bot.SendMessage(12345, "hi", bigDocument)
+	is.Error(err, telegram.ErrFileTooLarge)
+
+	_, err = bot.DownloadFile(telegram.File{FilePath: "videos/big.mp4", FileSize: telegram.MaxDownloadSize + 1})
+	is.Error(err, telegram.ErrFileTooLarge)
+
+	is.Equal(read(bot.DownloadFile(telegram.File{FilePath: "documents/file_1.txt"})), "remote content")
+	is.Equal(gotPath, "/file/bot"+validTestToken+"/documents/file_1.txt")
+
+	// local mode
+	bot, err = telegram.NewBot(validTestToken, telegram.SetClient(client), telegram.SetLocalMode())
+	is.NoError(err)
+
+	_, err = bot.SendMessage(12345, "hi", setDocument)
+	is.NoError(err)
+	is.Equal(gotParams.Get("document"), "file://"+filepath.ToSlash(localFile)) // local file is read by the server
+
+	is.Equal(read(bot.DownloadFile(telegram.File{FilePath: localFile, FileSize: telegram.MaxDownloadSize + 1})), "local content")
+}
@@ -0,0 +1,116 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// bulkRetries is the number of times a bulk method retries a message failing with ErrTooManyRequests.
+const bulkRetries = 3
+
+// ForwardMessages forwards the messages from the chat fromChatID to the chat chatID, one at a time and in order.
+// A message failing with ErrTooManyRequests is retried after the time Telegram asks to wait, a few times at most.
+// Set the rate limits of the bot with SetRateLimits to avoid hitting the flood limits in the first place,
+// the requests of the bulk methods are limited whatever the limited methods.
+//
+// The messages are forwarded even if some fail. The forwarded messages are returned in the order of messageIDs,
+// the ones of the failed messages are left empty, and the failures are reported with a BulkError.
+//
+//  Params: SetDisableNotification.
+func (bot *Bot) ForwardMessages(chatID, fromChatID int, messageIDs []int, params ...Param) ([]Message, error) {
+	return bot.ForwardMessagesContext(context.Background(), chatID, fromChatID, messageIDs, params...)
+}
+
+// ForwardMessagesContext is like ForwardMessages but with a context.
+// Once the context is done, the remaining messages are not forwarded and the context error is returned.
+func (bot *Bot) ForwardMessagesContext(ctx context.Context, chatID, fromChatID int, messageIDs []int, params ...Param) ([]Message, error) {
+	messages := make([]Message, len(messageIDs))
+	err := bulk(ctx, "forwardMessage", messageIDs, func(ctx context.Context, i int) (err error) {
+		messages[i], err = bot.ForwardMessageContext(ctx, chatID, fromChatID, messageIDs[i], params...)
+		return err
+	})
+	return messages, err
+}
+
+// CopyMessages copies the messages from the chat fromChatID to the chat chatID, one at a time and in order,
+// like ForwardMessages. The ids of the copies are returned in the order of messageIDs.
+//
+//  Params: SetCaption, SetParseMode, SetDisableNotification, SetReplyMarkup.
+func (bot *Bot) CopyMessages(chatID, fromChatID int, messageIDs []int, params ...Param) ([]MessageID, error) {
+	return bot.CopyMessagesContext(context.Background(), chatID, fromChatID, messageIDs, params...)
+}
+
+// CopyMessagesContext is like CopyMessages but with a context.
+func (bot *Bot) CopyMessagesContext(ctx context.Context, chatID, fromChatID int, messageIDs []int, params ...Param) ([]MessageID, error) {
+	ids := make([]MessageID, len(messageIDs))
+	err := bulk(ctx, "copyMessage", messageIDs, func(ctx context.Context, i int) (err error) {
+		ids[i], err = bot.CopyMessageContext(ctx, chatID, fromChatID, messageIDs[i], params...)
+		return err
+	})
+	return ids, err
+}
+
+// DeleteMessages deletes the messages of a chat, one at a time, like ForwardMessages.
+// The failures, such as a message already deleted, are reported with a BulkError.
+func (bot *Bot) DeleteMessages(chatID int, messageIDs []int) error {
+	return bot.DeleteMessagesContext(context.Background(), chatID, messageIDs)
+}
+
+// DeleteMessagesContext is like DeleteMessages but with a context.
+func (bot *Bot) DeleteMessagesContext(ctx context.Context, chatID int, messageIDs []int) error {
+	return bulk(ctx, "deleteMessage", messageIDs, func(ctx context.Context, i int) error {
+		_, err := bot.DeleteMessageContext(ctx, chatID, messageIDs[i])
+		return err
+	})
+}
+
+// bulk calls do for every message, retrying a message failing with ErrTooManyRequests,
+// and returns a BulkError of the failed messages, or the context error once the context is done.
+// The context given to do marks the requests as bulk requests, which are rate limited whatever their method.
+func bulk(ctx context.Context, methodName string, messageIDs []int, do func(ctx context.Context, i int) error) error {
+	bulkCtx := context.WithValue(ctx, bulkKey{}, true)
+
+	var bulkErr *BulkError
+	for i, messageID := range messageIDs {
+		err := do(bulkCtx, i)
+		for retry := 0; retry < bulkRetries && errors.Is(err, ErrTooManyRequests); retry++ {
+			if err := sleepContext(ctx, bulkWait(err)); err != nil {
+				return err
+			}
+			err = do(bulkCtx, i)
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			if bulkErr == nil {
+				bulkErr = &BulkError{Method: methodName}
+			}
+			bulkErr.Errors = append(bulkErr.Errors, MessageError{MessageID: messageID, Err: err})
+		}
+	}
+
+	if bulkErr != nil {
+		return bulkErr
+	}
+	return nil
+}
+
+// bulkKey is the context key marking the requests made by a bulk method.
+type bulkKey struct{}
+
+// isBulk reports whether the request with the context is made by a bulk method.
+func isBulk(ctx context.Context) bool {
+	bulk, _ := ctx.Value(bulkKey{}).(bool)
+	return bulk
+}
+
+// bulkWait returns how long to wait before repeating a request that failed with ErrTooManyRequests.
+func bulkWait(err error) time.Duration {
+	if wait := retryAfter(err); wait > 0 {
+		return wait
+	}
+	return time.Second
+}
@@ -0,0 +1,94 @@
+package telegram
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"time"
+)
+
+// CertificateValidity is how long a certificate generated by GenerateCertificate is valid.
+const CertificateValidity = 365 * 24 * time.Hour
+
+// GenerateCertificate returns a self-signed certificate and its private key in PEM format,
+// for a webhook served on the hosts, IP addresses or host names.
+// The first host is the common name of the certificate, it must match the host of the webhook url.
+//
+// The certificate is given to SetWebhook with SetCertificate, and the webhook is served with NewWebhookServer.
+//
+//	certPEM, keyPEM, err := telegram.GenerateCertificate("203.0.113.10")
+//	...
+//	bot.SetWebhook("https://203.0.113.10:8443/webhook",
+//		telegram.SetCertificate(telegram.InputFileReader("cert.pem", bytes.NewReader(certPEM))),
+//	)
+//
+// https://core.telegram.org/bots/self-signed
+func GenerateCertificate(hosts ...string) (certPEM, keyPEM []byte, err error) {
+	if len(hosts) == 0 {
+		return nil, nil, errors.New("telegram: certificate needs at least one host")
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	template := x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: hosts[0]},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(CertificateValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	return certPEM, keyPEM, nil
+}
+
+// NewWebhookServer returns an http.Server serving the handler, usually a WebhookHandler, on addr over TLS
+// with the certificate and its private key in PEM format, such as the ones returned by GenerateCertificate.
+// The server is started with ListenAndServeTLS("", "").
+func NewWebhookServer(addr string, handler http.Handler, certPEM, keyPEM []byte) (*http.Server, error) {
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Server{
+		Addr:    addr,
+		Handler: handler,
+		TLSConfig: &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		},
+	}, nil
+}
@@ -0,0 +1,53 @@
+package telegram_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/62bot/telegram"
+	"github.com/billyzaelani/is"
+)
+
+// TestGenerateCertificate tests serving a webhook over TLS with a generated self-signed certificate.
+func TestGenerateCertificate(t *testing.T) {
+	is := is.New(t)
+
+	certPEM, keyPEM, err := telegram.GenerateCertificate("127.0.0.1", "localhost")
+	is.NoError(err)
+
+	block, _ := pem.Decode(certPEM)
+	is.True(block != nil)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	is.NoError(err)
+	is.Equal(cert.Subject.CommonName, "127.0.0.1")
+	is.Equal(cert.DNSNames, []string{"localhost"})
+	is.True(len(cert.IPAddresses) == 1 && cert.IPAddresses[0].Equal(net.ParseIP("127.0.0.1")))
+
+	updates := make(chan telegram.Update, 1)
+	handler := telegram.NewWebhookHandler("", telegram.ChannelHandler(updates))
+	srv, err := telegram.NewWebhookServer("127.0.0.1:0", handler, certPEM, keyPEM)
+	is.NoError(err)
+
+	ln, err := net.Listen("tcp", srv.Addr)
+	is.NoError(err)
+	go srv.ServeTLS(ln, "", "")
+	defer srv.Close()
+
+	pool := x509.NewCertPool()
+	is.True(pool.AppendCertsFromPEM(certPEM))
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+
+	resp, err := client.Post("https://"+ln.Addr().String(), "application/json", strings.NewReader(`{"update_id":1}`))
+	is.NoError(err)
+	resp.Body.Close()
+	is.Equal(resp.StatusCode, http.StatusOK)
+	is.Equal((<-updates).UpdateID, 1)
+
+	_, _, err = telegram.GenerateCertificate()
+	is.Error(err) // a certificate needs a host
+}
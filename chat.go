@@ -0,0 +1,211 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// AdministratorRights are the rights of an administrator of a chat, see PromoteChatMember.
+// A right left false is revoked, promoting a user without any right demotes the user.
+//
+// https://core.telegram.org/bots/api#promotechatmember
+type AdministratorRights struct {
+	IsAnonymous         bool `json:"is_anonymous"`           // The presence of the administrator is hidden.
+	CanManageChat       bool `json:"can_manage_chat"`        // Access the event log, statistics and members, implied by any other right.
+	CanChangeInfo       bool `json:"can_change_info"`        // Change the chat title, photo and other settings.
+	CanPostMessages     bool `json:"can_post_messages"`      // Create channel posts, channels only.
+	CanEditMessages     bool `json:"can_edit_messages"`      // Edit messages of other users and pin messages, channels only.
+	CanDeleteMessages   bool `json:"can_delete_messages"`    // Delete messages of other users.
+	CanManageVoiceChats bool `json:"can_manage_voice_chats"` // Manage voice chats.
+	CanInviteUsers      bool `json:"can_invite_users"`       // Invite new users to the chat.
+	CanRestrictMembers  bool `json:"can_restrict_members"`   // Restrict, ban or unban chat members.
+	CanPinMessages      bool `json:"can_pin_messages"`       // Pin messages, supergroups only.
+	CanPromoteMembers   bool `json:"can_promote_members"`    // Add new administrators with a subset of their own rights.
+}
+
+// setParams sets each right as a param.
+func (r AdministratorRights) setParams(params *Params) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	var rights map[string]bool
+	if err := json.Unmarshal(b, &rights); err != nil {
+		return err
+	}
+	for field, v := range rights {
+		params.setJSON(field, strconv.FormatBool(v))
+	}
+	return nil
+}
+
+// SetUntilDate sets until_date param, when the user is unbanned or the restrictions are lifted.
+// A date less than 30 seconds or more than 366 days from now is forever.
+func SetUntilDate(t time.Time) Param {
+	return setParamInt("until_date", int(t.Unix()))
+}
+
+// SetUntilDuration sets until_date param to d from now, see SetUntilDate.
+func SetUntilDuration(d time.Duration) Param {
+	return func(params *Params) {
+		SetUntilDate(time.Now().Add(d))(params)
+	}
+}
+
+// SetRevokeMessages sets revoke_messages param, whether to delete all messages of the banned user from the chat.
+func SetRevokeMessages(b bool) Param {
+	return setParamBool("revoke_messages", b)
+}
+
+// SetOnlyIfBanned sets only_if_banned param, so UnbanChatMember doesn't remove a user that isn't banned from the chat.
+func SetOnlyIfBanned(b bool) Param {
+	return setParamBool("only_if_banned", b)
+}
+
+// BanChatMember bans a user from a group, a supergroup or a channel. The user can't return to the chat
+// on their own using invite links, etc., unless unbanned first. The bot must be an administrator of the chat
+// with the appropriate rights. Returns True on success.
+//
+//  Params: SetUntilDate, SetUntilDuration, SetRevokeMessages.
+//
+// https://core.telegram.org/bots/api#banchatmember
+func (bot *Bot) BanChatMember(chatID, userID int, params ...Param) (bool, error) {
+	return bot.BanChatMemberContext(context.Background(), chatID, userID, params...)
+}
+
+// BanChatMemberContext is like BanChatMember but with a context.
+func (bot *Bot) BanChatMemberContext(ctx context.Context, chatID, userID int, params ...Param) (bool, error) {
+	return bot.chatMemberRequest(ctx, "banChatMember", chatID, userID, resolveParam(params))
+}
+
+// KickChatMember is the former name of BanChatMember.
+//
+// Deprecated: use BanChatMember.
+//
+// https://core.telegram.org/bots/api#kickchatmember
+func (bot *Bot) KickChatMember(chatID, userID int, params ...Param) (bool, error) {
+	return bot.KickChatMemberContext(context.Background(), chatID, userID, params...)
+}
+
+// KickChatMemberContext is like KickChatMember but with a context.
+//
+// Deprecated: use BanChatMemberContext.
+func (bot *Bot) KickChatMemberContext(ctx context.Context, chatID, userID int, params ...Param) (bool, error) {
+	return bot.chatMemberRequest(ctx, "kickChatMember", chatID, userID, resolveParam(params))
+}
+
+// UnbanChatMember unbans a previously banned user from a supergroup or a channel.
+// The user isn't returned to the chat but is able to join it. By default, a user that is a member of the chat
+// is removed from the chat too, use SetOnlyIfBanned to prevent it. Returns True on success.
+//
+//  Params: SetOnlyIfBanned.
+//
+// https://core.telegram.org/bots/api#unbanchatmember
+func (bot *Bot) UnbanChatMember(chatID, userID int, params ...Param) (bool, error) {
+	return bot.UnbanChatMemberContext(context.Background(), chatID, userID, params...)
+}
+
+// UnbanChatMemberContext is like UnbanChatMember but with a context.
+func (bot *Bot) UnbanChatMemberContext(ctx context.Context, chatID, userID int, params ...Param) (bool, error) {
+	return bot.chatMemberRequest(ctx, "unbanChatMember", chatID, userID, resolveParam(params))
+}
+
+// RestrictChatMember restricts a user in a supergroup to the permissions, a permission left false is denied.
+// Use the default permissions of the chat to lift the restrictions. Returns True on success.
+//
+//  Params: SetUntilDate, SetUntilDuration.
+//
+// https://core.telegram.org/bots/api#restrictchatmember
+func (bot *Bot) RestrictChatMember(chatID, userID int, permissions ChatPermissions, params ...Param) (bool, error) {
+	return bot.RestrictChatMemberContext(context.Background(), chatID, userID, permissions, params...)
+}
+
+// RestrictChatMemberContext is like RestrictChatMember but with a context.
+func (bot *Bot) RestrictChatMemberContext(ctx context.Context, chatID, userID int, permissions ChatPermissions, params ...Param) (bool, error) {
+	p := resolveParam(params)
+	if err := p.SetJSON("permissions", permissions); err != nil {
+		return false, err
+	}
+	return bot.chatMemberRequest(ctx, "restrictChatMember", chatID, userID, p)
+}
+
+// PromoteChatMember promotes or demotes a user in a supergroup or a channel to an administrator with the rights.
+// Returns True on success.
+//
+// https://core.telegram.org/bots/api#promotechatmember
+func (bot *Bot) PromoteChatMember(chatID, userID int, rights AdministratorRights, params ...Param) (bool, error) {
+	return bot.PromoteChatMemberContext(context.Background(), chatID, userID, rights, params...)
+}
+
+// PromoteChatMemberContext is like PromoteChatMember but with a context.
+func (bot *Bot) PromoteChatMemberContext(ctx context.Context, chatID, userID int, rights AdministratorRights, params ...Param) (bool, error) {
+	p := resolveParam(params)
+	if err := rights.setParams(p); err != nil {
+		return false, err
+	}
+	return bot.chatMemberRequest(ctx, "promoteChatMember", chatID, userID, p)
+}
+
+// SetChatAdministratorCustomTitle sets a custom title, 0-16 characters without emoji,
+// for an administrator in a supergroup promoted by the bot. Returns True on success.
+//
+// https://core.telegram.org/bots/api#setchatadministratorcustomtitle
+func (bot *Bot) SetChatAdministratorCustomTitle(chatID, userID int, customTitle string) (bool, error) {
+	return bot.SetChatAdministratorCustomTitleContext(context.Background(), chatID, userID, customTitle)
+}
+
+// SetChatAdministratorCustomTitleContext is like SetChatAdministratorCustomTitle but with a context.
+func (bot *Bot) SetChatAdministratorCustomTitleContext(ctx context.Context, chatID, userID int, customTitle string) (bool, error) {
+	p := resolveParam([]Param{setParamString("custom_title", customTitle)})
+	return bot.chatMemberRequest(ctx, "setChatAdministratorCustomTitle", chatID, userID, p)
+}
+
+// SetChatPermissions sets the default permissions of all members of a group or a supergroup,
+// a permission left false is denied. The bot must be an administrator of the chat with the can_restrict_members right.
+// Returns True on success.
+//
+// https://core.telegram.org/bots/api#setchatpermissions
+func (bot *Bot) SetChatPermissions(chatID int, permissions ChatPermissions) (bool, error) {
+	return bot.SetChatPermissionsContext(context.Background(), chatID, permissions)
+}
+
+// SetChatPermissionsContext is like SetChatPermissions but with a context.
+func (bot *Bot) SetChatPermissionsContext(ctx context.Context, chatID int, permissions ChatPermissions) (bool, error) {
+	p := resolveParam([]Param{setParamInt("chat_id", chatID)})
+	if err := p.SetJSON("permissions", permissions); err != nil {
+		return false, err
+	}
+
+	resp, err := bot.makeRequest(ctx, "setChatPermissions", p)
+	if err != nil {
+		return false, err
+	}
+
+	var ok bool
+	if err := resp.decode(&ok); err != nil {
+		return false, err
+	}
+
+	return ok, nil
+}
+
+// chatMemberRequest makes a request about a member of a chat, which returns True on success.
+func (bot *Bot) chatMemberRequest(ctx context.Context, methodName string, chatID, userID int, params *Params) (bool, error) {
+	params.setJSON("chat_id", strconv.Itoa(chatID))
+	params.setJSON("user_id", strconv.Itoa(userID))
+
+	resp, err := bot.makeRequest(ctx, methodName, params)
+	if err != nil {
+		return false, err
+	}
+
+	var ok bool
+	if err := resp.decode(&ok); err != nil {
+		return false, err
+	}
+
+	return ok, nil
+}
@@ -0,0 +1,106 @@
+package telegram_test
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/62bot/telegram"
+	"github.com/billyzaelani/is"
+)
+
+var untilDate = time.Unix(1700000000, 0)
+
+func banChatMemberOK(is *is.Is, bot *telegram.Bot) {
+	ok, err := bot.BanChatMember(-1001234, 12345,
+		telegram.SetUntilDate(untilDate),
+		telegram.SetRevokeMessages(true),
+	)
+	is.NoError(err)
+
+	is.True(ok)
+}
+
+func banChatMemberNotEnoughRights(is *is.Is, bot *telegram.Bot) {
+	_, err := bot.BanChatMember(-1001234, 12345)
+	var botError *telegram.BotError
+	is.ErrorAs(err, &botError)
+	is.Equal(botError.Code, http.StatusBadRequest)
+}
+
+func kickChatMemberOK(is *is.Is, bot *telegram.Bot) {
+	ok, err := bot.KickChatMember(-1001234, 12345)
+	is.NoError(err)
+
+	is.True(ok)
+}
+
+func unbanChatMemberOnlyIfBanned(is *is.Is, bot *telegram.Bot) {
+	ok, err := bot.UnbanChatMember(-1001234, 12345, telegram.SetOnlyIfBanned(true))
+	is.NoError(err)
+
+	is.True(ok)
+}
+
+func restrictChatMemberOK(is *is.Is, bot *telegram.Bot) {
+	ok, err := bot.RestrictChatMember(-1001234, 12345,
+		telegram.ChatPermissions{CanSendMessages: true},
+		telegram.SetUntilDate(untilDate),
+	)
+	is.NoError(err)
+
+	is.True(ok)
+}
+
+func promoteChatMemberOK(is *is.Is, bot *telegram.Bot) {
+	ok, err := bot.PromoteChatMember(-1001234, 12345, telegram.AdministratorRights{
+		CanManageChat:       true,
+		CanChangeInfo:       true,
+		CanDeleteMessages:   true,
+		CanManageVoiceChats: true,
+		CanInviteUsers:      true,
+		CanRestrictMembers:  true,
+		CanPinMessages:      true,
+	})
+	is.NoError(err)
+
+	is.True(ok)
+}
+
+func setChatAdministratorCustomTitleOK(is *is.Is, bot *telegram.Bot) {
+	ok, err := bot.SetChatAdministratorCustomTitle(-1001234, 12345, "moderator")
+	is.NoError(err)
+
+	is.True(ok)
+}
+
+func setChatPermissionsOK(is *is.Is, bot *telegram.Bot) {
+	ok, err := bot.SetChatPermissions(-1001234, telegram.ChatPermissions{
+		CanSendMessages:      true,
+		CanSendMediaMessages: true,
+	})
+	is.NoError(err)
+
+	is.True(ok)
+}
+
+// TestSetUntilDuration tests that a duration is sent as the date it ends.
+func TestSetUntilDuration(t *testing.T) {
+	is := is.New(t)
+
+	var untilDate int
+	client := newTestClient(func(methodName string, params url.Values) *http.Response {
+		untilDate, _ = strconv.Atoi(params.Get("until_date"))
+		return newHTTPResponse(http.StatusOK, []byte(`{"ok":true,"result":true}`))
+	})
+	bot, err := telegram.NewBot(validTestToken, telegram.SetClient(client), telegram.SetLazy())
+	is.NoError(err)
+
+	want := time.Now().Add(time.Hour).Unix()
+	_, err = bot.RestrictChatMember(-1001234, 12345, telegram.ChatPermissions{}, telegram.SetUntilDuration(time.Hour))
+	is.NoError(err)
+
+	is.True(int64(untilDate) >= want && int64(untilDate) <= want+1) // an hour from now
+}
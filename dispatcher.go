@@ -0,0 +1,128 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+)
+
+// ErrStopPropagation is returned by a handler of a Dispatcher to stop the update
+// from reaching the handlers of the next groups. It isn't reported as an error.
+var ErrStopPropagation = errors.New("telegram: stop propagation")
+
+// Dispatcher is an UpdateHandler routing updates to the handlers registered with a Filter.
+// It's given to a Poller or a WebhookHandler as the UpdateHandler.
+//
+// Handlers are registered in groups. The groups are tried in ascending order,
+// and in each group the update goes to the first handler, in the order of registration, whose filter matches.
+// Handling continues with the next group unless the handler returns ErrStopPropagation.
+//
+//	dispatcher := telegram.NewDispatcher()
+//	dispatcher.HandleFunc(telegram.CommandFor(bot, "start"), start)
+//	dispatcher.HandleFunc(telegram.CallbackData("vote:"), vote)
+//	dispatcher.HandleGroupFunc(-1, telegram.All, logUpdate) // runs before the other handlers
+//
+//	err := telegram.NewPoller(bot).Run(ctx, dispatcher)
+type Dispatcher struct {
+	// ErrorHandler is called with the error of a handler other than ErrStopPropagation,
+	// handling continues with the next group. The error it returns, if any, is returned by HandleUpdate.
+	// The default error handler is DefaultErrorHandler.
+	ErrorHandler func(ctx context.Context, update Update, err error) error
+
+	mu     sync.RWMutex
+	groups []*handlerGroup
+}
+
+// handlerGroup is a group of handlers of a Dispatcher.
+type handlerGroup struct {
+	group  int
+	routes []route
+}
+
+// route is a handler with its filter.
+type route struct {
+	filter  Filter
+	handler UpdateHandler
+}
+
+// NewDispatcher returns a new Dispatcher without handlers.
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{ErrorHandler: DefaultErrorHandler}
+}
+
+// DefaultErrorHandler logs the error of a handler with the update id using the standard logger.
+func DefaultErrorHandler(ctx context.Context, update Update, err error) error {
+	log.Printf("telegram: handling update %d: %v", update.UpdateID, err)
+	return nil
+}
+
+// Handle registers the handler for the updates matched by filter, in group 0.
+func (d *Dispatcher) Handle(filter Filter, handler UpdateHandler) {
+	d.HandleGroup(0, filter, handler)
+}
+
+// HandleFunc registers the handler function for the updates matched by filter, in group 0.
+func (d *Dispatcher) HandleFunc(filter Filter, handler func(ctx context.Context, update Update) error) {
+	d.HandleGroup(0, filter, UpdateHandlerFunc(handler))
+}
+
+// HandleGroup registers the handler for the updates matched by filter, in the group.
+// Groups with a lower number are tried first.
+func (d *Dispatcher) HandleGroup(group int, filter Filter, handler UpdateHandler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	i := sort.Search(len(d.groups), func(i int) bool { return d.groups[i].group >= group })
+	if i == len(d.groups) || d.groups[i].group != group {
+		d.groups = append(d.groups, nil)
+		copy(d.groups[i+1:], d.groups[i:])
+		d.groups[i] = &handlerGroup{group: group}
+	}
+	d.groups[i].routes = append(d.groups[i].routes, route{filter: filter, handler: handler})
+}
+
+// HandleGroupFunc registers the handler function for the updates matched by filter, in the group.
+func (d *Dispatcher) HandleGroupFunc(group int, filter Filter, handler func(ctx context.Context, update Update) error) {
+	d.HandleGroup(group, filter, UpdateHandlerFunc(handler))
+}
+
+// HandleUpdate routes the update to the matching handlers, see Dispatcher.
+func (d *Dispatcher) HandleUpdate(ctx context.Context, update Update) error {
+	for _, handler := range d.handlers(update) {
+		err := handler.HandleUpdate(ctx, update)
+		if errors.Is(err, ErrStopPropagation) {
+			return nil
+		}
+		if err == nil {
+			continue
+		}
+
+		errorHandler := d.ErrorHandler
+		if errorHandler == nil {
+			errorHandler = DefaultErrorHandler
+		}
+		if err := errorHandler(ctx, update, err); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handlers returns the handler of each group matching the update, in the order they're called.
+func (d *Dispatcher) handlers(update Update) []UpdateHandler {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var handlers []UpdateHandler
+	for _, group := range d.groups {
+		for _, route := range group.routes {
+			if route.filter(update) {
+				handlers = append(handlers, route.handler)
+				break
+			}
+		}
+	}
+	return handlers
+}
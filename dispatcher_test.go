@@ -0,0 +1,137 @@
+package telegram_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/62bot/telegram"
+	"github.com/billyzaelani/is"
+)
+
+func decodeUpdate(is *is.Is, s string) telegram.Update {
+	var update telegram.Update
+	is.NoError(json.Unmarshal([]byte(s), &update))
+	return update
+}
+
+// TestFilter tests matching updates with filters.
+func TestFilter(t *testing.T) {
+	const (
+		start     = `{"update_id":1,"message":{"message_id":1,"from":{"id":2},"chat":{"id":2,"type":"private"},"text":"/start@my_bot a b"}}`
+		photo     = `{"update_id":1,"message":{"message_id":1,"chat":{"id":-3,"type":"group"},"photo":[{"file_id":"f"}],"caption":"order 42"}}`
+		callback  = `{"update_id":1,"callback_query":{"id":"1","from":{"id":2},"message":{"message_id":1,"chat":{"id":2,"type":"private"},"text":"order 1"},"data":"vote:up"}}`
+		inline    = `{"update_id":1,"inline_query":{"id":"1","from":{"id":2},"query":"q","offset":""}}`
+		location  = `{"update_id":1,"edited_message":{"message_id":1,"chat":{"id":2,"type":"private"},"location":{"latitude":1,"longitude":2}}}`
+		payment   = `{"update_id":1,"message":{"message_id":1,"chat":{"id":2,"type":"private"},"successful_payment":{"currency":"USD","total_amount":100}}}`
+		document  = `{"update_id":1,"channel_post":{"message_id":1,"chat":{"id":-100,"type":"channel"},"document":{"file_id":"f"}}}`
+		startText = `{"update_id":1,"message":{"message_id":1,"chat":{"id":2,"type":"private"},"text":"/starting"}}`
+		startBot  = `{"update_id":1,"message":{"message_id":1,"chat":{"id":-3,"type":"group"},"text":"/start@Test_Bot"}}`
+		plain     = `{"update_id":1,"message":{"message_id":1,"chat":{"id":-3,"type":"group"},"text":"/start"}}`
+	)
+
+	bot, err := telegram.NewBot(validTestToken, telegram.SetClient(newTestClient(func(methodName string, params url.Values) *http.Response {
+		return newHTTPResponse(authorizedCase.StatusCode, authorizedCase.Body)
+	})))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	orderRe := regexp.MustCompile(`order \d+`)
+	tests := map[string]struct {
+		filter telegram.Filter
+		update string
+		want   bool
+	}{
+		"command":                 {filter: telegram.Command("start"), update: plain, want: true},
+		"command_addressed":       {filter: telegram.Command("start"), update: start, want: false},
+		"command_for":             {filter: telegram.CommandFor(bot, "start"), update: plain, want: true},
+		"command_for_bot":         {filter: telegram.CommandFor(bot, "start"), update: startBot, want: true},
+		"command_for_other_bot":   {filter: telegram.CommandFor(bot, "start"), update: start, want: false},
+		"command_other":           {filter: telegram.Command("start"), update: startText, want: false},
+		"command_no_message":      {filter: telegram.Command("start"), update: inline, want: false},
+		"regexp_caption":          {filter: telegram.Regexp(orderRe), update: photo, want: true},
+		"regexp_callback_message": {filter: telegram.Regexp(orderRe), update: callback, want: false},
+		"callback_data":           {filter: telegram.CallbackData("vote:"), update: callback, want: true},
+		"callback_data_other":     {filter: telegram.CallbackData("poll:"), update: callback, want: false},
+		"chat_type":               {filter: telegram.ChatType(telegram.ChatTypeGroup, telegram.ChatTypeSupergroup), update: photo, want: true},
+		"chat_type_inline":        {filter: telegram.ChatType(telegram.ChatTypePrivate), update: inline, want: false},
+		"inline_query":            {filter: telegram.Types(telegram.UpdateInlineQuery), update: inline, want: true},
+		"photo":                   {filter: telegram.HasPhoto, update: photo, want: true},
+		"document":                {filter: telegram.HasDocument, update: document, want: true},
+		"location":                {filter: telegram.HasLocation, update: location, want: true},
+		"successful_payment":      {filter: telegram.HasSuccessfulPayment, update: payment, want: true},
+		"no_photo":                {filter: telegram.HasPhoto, update: start, want: false},
+		"and":                     {filter: telegram.And(telegram.HasPhoto, telegram.ChatType(telegram.ChatTypePrivate)), update: photo, want: false},
+		"or":                      {filter: telegram.Or(telegram.HasDocument, telegram.HasPhoto), update: photo, want: true},
+		"not":                     {filter: telegram.Not(telegram.HasPhoto), update: photo, want: false},
+		"all":                     {filter: telegram.All, update: inline, want: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			is := is.New(t)
+			is.Equal(tt.filter(decodeUpdate(is, tt.update)), tt.want)
+		})
+	}
+
+	is := is.New(t)
+	is.Equal(telegram.CommandArgs("/start@my_bot a b"), "a b")
+	is.Equal(telegram.CommandArgs("/start"), "")
+}
+
+// TestDispatcher tests routing updates to handlers by group.
+func TestDispatcher(t *testing.T) {
+	is := is.New(t)
+
+	var calls []string
+	handler := func(name string, err error) func(ctx context.Context, update telegram.Update) error {
+		return func(ctx context.Context, update telegram.Update) error {
+			calls = append(calls, name)
+			return err
+		}
+	}
+
+	errHandler := errors.New("handler failed")
+	var handlerErrors []error
+
+	dispatcher := telegram.NewDispatcher()
+	dispatcher.ErrorHandler = func(ctx context.Context, update telegram.Update, err error) error {
+		handlerErrors = append(handlerErrors, err)
+		return nil
+	}
+	dispatcher.HandleFunc(telegram.Command("start"), handler("start", nil))
+	dispatcher.HandleFunc(telegram.Command("stop"), handler("stop", telegram.ErrStopPropagation))
+	dispatcher.HandleFunc(telegram.Command("fail"), handler("fail", errHandler))
+	dispatcher.HandleFunc(telegram.All, handler("fallback", nil))
+	dispatcher.HandleGroupFunc(1, telegram.All, handler("after", nil))
+	dispatcher.HandleGroupFunc(-1, telegram.All, handler("before", nil))
+
+	tests := []struct {
+		text      string
+		wantCalls []string
+	}{
+		{text: "/start", wantCalls: []string{"before", "start", "after"}},
+		{text: "/stop", wantCalls: []string{"before", "stop"}},
+		{text: "/fail", wantCalls: []string{"before", "fail", "after"}},
+		{text: "hello", wantCalls: []string{"before", "fallback", "after"}},
+	}
+
+	for _, tt := range tests {
+		calls = nil
+		update := telegram.Update{Message: &telegram.Message{Text: tt.text, Chat: &telegram.Chat{}}}
+		is.NoError(dispatcher.HandleUpdate(context.Background(), update))
+		is.Equal(calls, tt.wantCalls) // handlers called for the text
+	}
+	is.Equal(handlerErrors, []error{errHandler})
+
+	dispatcher.ErrorHandler = func(ctx context.Context, update telegram.Update, err error) error {
+		return err
+	}
+	err := dispatcher.HandleUpdate(context.Background(), telegram.Update{Message: &telegram.Message{Text: "/fail"}})
+	is.Error(err, errHandler) // error returned by the error handler
+}
@@ -0,0 +1,49 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// dryRun is the terminal Invoker of a bot made with SetDryRun.
+type dryRun struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// send writes the request as a JSON line instead of sending it, and answers with a null result.
+// Files to upload are written as their file name.
+func (d *dryRun) send(ctx context.Context, methodName string, params *Params) (*Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	object, err := params.jsonObject()
+	if err != nil {
+		return nil, err
+	}
+	for field, file := range params.files {
+		if object[field], err = json.Marshal(file.name); err != nil {
+			return nil, err
+		}
+	}
+	if object["method"], err = json.Marshal(methodName); err != nil {
+		return nil, err
+	}
+
+	line, err := json.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	_, err = d.w.Write(append(line, '\n'))
+	d.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Response{OK: true, Result: json.RawMessage("null"), methodName: methodName}, nil
+}
@@ -0,0 +1,152 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strconv"
+)
+
+// EditTarget is the message to edit, either a message of a chat or an inline message sent via the bot.
+// It's created with ChatMessage or InlineMessage, or with CallbackQuery.EditTarget for the message of a button.
+type EditTarget struct {
+	ChatID          int    // Chat of the message, with MessageID.
+	MessageID       int    // Message in the chat, with ChatID.
+	InlineMessageID string // Inline message, instead of ChatID and MessageID.
+}
+
+// ChatMessage returns the EditTarget of a message of a chat.
+func ChatMessage(chatID, messageID int) EditTarget {
+	return EditTarget{ChatID: chatID, MessageID: messageID}
+}
+
+// InlineMessage returns the EditTarget of an inline message.
+func InlineMessage(inlineMessageID string) EditTarget {
+	return EditTarget{InlineMessageID: inlineMessageID}
+}
+
+// EditTarget returns the EditTarget of the message with the button of the callback query,
+// the inline message when the button was attached to a message sent via the bot.
+func (q *CallbackQuery) EditTarget() EditTarget {
+	if q.InlineMessageID != "" || q.Message == nil {
+		return InlineMessage(q.InlineMessageID)
+	}
+	return ChatMessage(q.Message.Chat.ID, q.Message.MessageID)
+}
+
+// setParams sets the params identifying the message.
+func (t EditTarget) setParams(params *Params) {
+	if t.InlineMessageID != "" {
+		params.Set("inline_message_id", t.InlineMessageID)
+		return
+	}
+	params.setJSON("chat_id", strconv.Itoa(t.ChatID))
+	params.setJSON("message_id", strconv.Itoa(t.MessageID))
+}
+
+// EditResult is the result of editing a message.
+type EditResult struct {
+	// Message is the edited message of a chat. It's nil for an inline message, or when the message is not modified.
+	Message *Message
+
+	// NotModified is true when the message already had the new content, so it's left as is.
+	// It's not reported as an error, unlike the ErrMessageNotModified of the Telegram API.
+	NotModified bool
+}
+
+// EditMessageText edits a text or game message. The reply markup can only be an inline keyboard.
+//
+//  Params: SetParseMode, SetReplyMarkup.
+//
+// https://core.telegram.org/bots/api#editmessagetext
+func (bot *Bot) EditMessageText(target EditTarget, text string, params ...Param) (EditResult, error) {
+	return bot.EditMessageTextContext(context.Background(), target, text, params...)
+}
+
+// EditMessageTextContext is like EditMessageText but with a context.
+func (bot *Bot) EditMessageTextContext(ctx context.Context, target EditTarget, text string, params ...Param) (EditResult, error) {
+	p := resolveParam(params)
+	p.Set("text", text)
+	return bot.editMessage(ctx, "editMessageText", target, p)
+}
+
+// EditMessageCaption edits the caption of a message. The reply markup can only be an inline keyboard.
+//
+//  Params: SetParseMode, SetReplyMarkup.
+//
+// https://core.telegram.org/bots/api#editmessagecaption
+func (bot *Bot) EditMessageCaption(target EditTarget, caption string, params ...Param) (EditResult, error) {
+	return bot.EditMessageCaptionContext(context.Background(), target, caption, params...)
+}
+
+// EditMessageCaptionContext is like EditMessageCaption but with a context.
+func (bot *Bot) EditMessageCaptionContext(ctx context.Context, target EditTarget, caption string, params ...Param) (EditResult, error) {
+	p := resolveParam(params)
+	p.Set("caption", caption)
+	return bot.editMessage(ctx, "editMessageCaption", target, p)
+}
+
+// EditMessageMedia edits the animation, audio, document, photo, or video of a message.
+// The media of an album can only be replaced by a media of a type that can be mixed with the album, see SendMediaGroup.
+// A new file can't be uploaded for an inline message, use a file_id or an URL instead.
+// The reply markup can only be an inline keyboard.
+//
+//  Params: SetReplyMarkup.
+//
+// https://core.telegram.org/bots/api#editmessagemedia
+func (bot *Bot) EditMessageMedia(target EditTarget, media InputMedia, params ...Param) (EditResult, error) {
+	return bot.EditMessageMediaContext(context.Background(), target, media, params...)
+}
+
+// EditMessageMediaContext is like EditMessageMedia but with a context.
+func (bot *Bot) EditMessageMediaContext(ctx context.Context, target EditTarget, media InputMedia, params ...Param) (EditResult, error) {
+	p := resolveParam(params)
+	object, err := media.encodeMedia(p, "media")
+	if err != nil {
+		return EditResult{}, err
+	}
+	p.setJSON("media", string(object))
+	return bot.editMessage(ctx, "editMessageMedia", target, p)
+}
+
+// EditMessageReplyMarkup edits the inline keyboard of a message, a nil markup removes the keyboard.
+//
+// https://core.telegram.org/bots/api#editmessagereplymarkup
+func (bot *Bot) EditMessageReplyMarkup(target EditTarget, markup *InlineKeyboardMarkup, params ...Param) (EditResult, error) {
+	return bot.EditMessageReplyMarkupContext(context.Background(), target, markup, params...)
+}
+
+// EditMessageReplyMarkupContext is like EditMessageReplyMarkup but with a context.
+func (bot *Bot) EditMessageReplyMarkupContext(ctx context.Context, target EditTarget, markup *InlineKeyboardMarkup, params ...Param) (EditResult, error) {
+	p := resolveParam(params)
+	if markup != nil {
+		if err := p.SetJSON("reply_markup", markup); err != nil {
+			return EditResult{}, err
+		}
+	}
+	return bot.editMessage(ctx, "editMessageReplyMarkup", target, p)
+}
+
+// editMessage edits the target message, the result is the edited message of a chat or true for an inline message.
+func (bot *Bot) editMessage(ctx context.Context, methodName string, target EditTarget, params *Params) (EditResult, error) {
+	target.setParams(params)
+
+	resp, err := bot.makeRequest(ctx, methodName, params)
+	if errors.Is(err, ErrMessageNotModified) {
+		return EditResult{NotModified: true}, nil
+	}
+	if err != nil {
+		return EditResult{}, err
+	}
+
+	if bytes.Equal(bytes.TrimSpace(resp.Result), []byte("true")) {
+		return EditResult{}, nil
+	}
+
+	var message Message
+	if err := resp.decode(&message); err != nil {
+		return EditResult{}, err
+	}
+
+	return EditResult{Message: &message}, nil
+}
@@ -0,0 +1,171 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Errors reported by the Telegram API. They're matched against the BotError using errors.Is:
+//
+//	if errors.Is(err, telegram.ErrBotBlocked) {
+//		// stop sending messages to the user.
+//	}
+var (
+	ErrUnauthorized       = errors.New("telegram: unauthorized")
+	ErrForbidden          = errors.New("telegram: forbidden")
+	ErrBotBlocked         = errors.New("telegram: bot was blocked by the user")
+	ErrChatNotFound       = errors.New("telegram: chat not found")
+	ErrMessageNotModified = errors.New("telegram: message is not modified")
+	ErrTooManyRequests    = errors.New("telegram: too many requests")
+	ErrConflict           = errors.New("telegram: conflict")
+	ErrChatMigrated       = errors.New("telegram: group chat was migrated to a supergroup")
+)
+
+// Errors reported by the bot before making a request.
+var (
+	ErrInvalidToken      = errors.New("telegram: invalid token")       // The token is not in the form <id>:<secret>.
+	ErrFileTooLarge      = errors.New("telegram: file is too large")   // The file exceeds the size limit of the Telegram API.
+	ErrInvalidMediaGroup = errors.New("telegram: invalid media group") // The media group has too few or too many media, or media that can't be mixed.
+)
+
+// BotError records an error code (http status code) and the description.
+// Parameters, when present, tells why the request was unsuccessful
+// and how it can be repeated, for example after RetryAfter seconds.
+type BotError struct {
+	Code        int
+	Description string
+	Parameters  *ResponseParameters
+}
+
+// newBotError returns the error of an unsuccessful response.
+// It's a ChatMigratedError if the response tells the chat was migrated.
+func newBotError(resp Response) error {
+	err := &BotError{Code: resp.ErrorCode, Description: resp.Description, Parameters: resp.Parameters}
+	if resp.Parameters != nil && resp.Parameters.MigrateToChatID != 0 {
+		return &ChatMigratedError{MigrateToChatID: resp.Parameters.MigrateToChatID, Err: err}
+	}
+	return err
+}
+
+// RetryAfter returns how long to wait before repeating the request, as told by Telegram
+// when the request failed with ErrTooManyRequests, or 0 when not told.
+func (e *BotError) RetryAfter() time.Duration {
+	if e.Parameters == nil || e.Parameters.RetryAfter <= 0 {
+		return 0
+	}
+	return time.Duration(e.Parameters.RetryAfter) * time.Second
+}
+
+// retryAfter returns the RetryAfter of the BotError in err, or 0 when there's none.
+func retryAfter(err error) time.Duration {
+	var botError *BotError
+	if errors.As(err, &botError) {
+		return botError.RetryAfter()
+	}
+	return 0
+}
+
+func (e *BotError) Error() string {
+	return fmt.Sprintf("%d %s", e.Code, e.Description)
+}
+
+// Is reports whether the error is of the kind of target, one of the ErrX errors.
+func (e *BotError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.Code == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.Code == http.StatusForbidden
+	case ErrBotBlocked:
+		return e.Code == http.StatusForbidden && e.describes("bot was blocked by the user")
+	case ErrChatNotFound:
+		return e.Code == http.StatusBadRequest && e.describes("chat not found")
+	case ErrMessageNotModified:
+		return e.Code == http.StatusBadRequest && e.describes("message is not modified")
+	case ErrTooManyRequests:
+		return e.Code == http.StatusTooManyRequests
+	case ErrConflict:
+		return e.Code == http.StatusConflict
+	case ErrChatMigrated:
+		return e.Parameters != nil && e.Parameters.MigrateToChatID != 0
+	default:
+		return false
+	}
+}
+
+// describes reports whether the description contains s, ignoring the case.
+func (e *BotError) describes(s string) bool {
+	return strings.Contains(strings.ToLower(e.Description), s)
+}
+
+// ChatMigratedError is returned when a group chat was migrated to a supergroup.
+// Requests to the chat have to be sent to the supergroup, MigrateToChatID, from now on.
+type ChatMigratedError struct {
+	MigrateToChatID int
+	Err             *BotError
+}
+
+func (e *ChatMigratedError) Error() string {
+	return fmt.Sprintf("%v: migrated to chat %d", e.Err, e.MigrateToChatID)
+}
+
+// Is reports whether target is ErrChatMigrated.
+func (e *ChatMigratedError) Is(target error) bool {
+	return target == ErrChatMigrated
+}
+
+func (e *ChatMigratedError) Unwrap() error {
+	return e.Err
+}
+
+// NetworkError records a failure to reach the Telegram API.
+type NetworkError struct {
+	Method string
+	Err    error
+}
+
+func (e *NetworkError) Error() string {
+	return fmt.Sprintf("telegram: %s: %v", e.Method, e.Err)
+}
+
+func (e *NetworkError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeError records a failure to decode a response of the Telegram API.
+type DecodeError struct {
+	Method string
+	Err    error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("telegram: %s: decode response: %v", e.Method, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// BulkError records the failures of a bulk method such as DeleteMessages, one error per failed message.
+type BulkError struct {
+	Method string
+	Errors []MessageError
+}
+
+// MessageError records the failure of a bulk method for a message.
+type MessageError struct {
+	MessageID int
+	Err       error
+}
+
+func (e *BulkError) Error() string {
+	return fmt.Sprintf("telegram: %s: %d messages failed, message %d: %v", e.Method, len(e.Errors), e.Errors[0].MessageID, e.Errors[0].Err)
+}
+
+// Unwrap returns the error of the first failed message.
+func (e *BulkError) Unwrap() error {
+	return e.Errors[0].Err
+}
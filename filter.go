@@ -0,0 +1,187 @@
+package telegram
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Filter reports whether an update matches, it selects the updates a Dispatcher routes to a handler.
+// Filters are composed with And, Or and Not.
+//
+//	telegram.And(telegram.Command("start"), telegram.ChatType(telegram.ChatTypePrivate))
+type Filter func(update Update) bool
+
+// And returns a Filter matching an update matched by all the filters.
+func And(filters ...Filter) Filter {
+	return func(update Update) bool {
+		for _, filter := range filters {
+			if !filter(update) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or returns a Filter matching an update matched by any of the filters.
+func Or(filters ...Filter) Filter {
+	return func(update Update) bool {
+		for _, filter := range filters {
+			if filter(update) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not returns a Filter matching an update not matched by the filter.
+func Not(filter Filter) Filter {
+	return func(update Update) bool {
+		return !filter(update)
+	}
+}
+
+// All is a Filter matching any update.
+func All(update Update) bool {
+	return true
+}
+
+// Types returns a Filter matching an update of one of the types,
+// such as UpdateInlineQuery for inline queries.
+func Types(types ...UpdateType) Filter {
+	return func(update Update) bool {
+		updateType := update.Type()
+		for _, t := range types {
+			if updateType == t {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Command returns a Filter matching a new message starting with the command, given without the slash,
+// and possibly followed by arguments, see CommandArgs.
+// A command addressed to a bot, as in "/start@my_bot", isn't matched since it may be meant for another bot
+// of a group, use CommandFor to match the commands addressed to the bot too.
+func Command(name string) Filter {
+	return func(update Update) bool {
+		if update.Message == nil {
+			return false
+		}
+		command, username, _ := splitCommand(update.Message.Text)
+		return command == name && username == ""
+	}
+}
+
+// CommandFor returns a Filter like Command also matching the command addressed to the bot, as in "/start@my_bot".
+// The same command addressed to another bot isn't matched.
+// The bot username is known from Bot.Me, a lazy bot calls GetMe on the first command addressed to a bot.
+func CommandFor(bot *Bot, name string) Filter {
+	return func(update Update) bool {
+		if update.Message == nil {
+			return false
+		}
+		command, username, _ := splitCommand(update.Message.Text)
+		if command != name {
+			return false
+		}
+		if username == "" {
+			return true
+		}
+		me, err := bot.Me()
+		return err == nil && strings.EqualFold(username, me.Username)
+	}
+}
+
+// CommandArgs returns the arguments following the command of a message text,
+// "a b" for "/start a b", or an empty string when there's none.
+func CommandArgs(text string) string {
+	_, _, args := splitCommand(text)
+	return args
+}
+
+// splitCommand returns the command of text without the slash, the bot username it's addressed to if any,
+// and its arguments. The command is empty when text doesn't start with a command.
+func splitCommand(text string) (command, username, args string) {
+	if !strings.HasPrefix(text, "/") {
+		return "", "", ""
+	}
+
+	command = text[1:]
+	if i := strings.IndexAny(command, " \n\t"); i >= 0 {
+		command, args = command[:i], strings.TrimSpace(command[i+1:])
+	}
+	if i := strings.IndexByte(command, '@'); i >= 0 {
+		command, username = command[:i], command[i+1:]
+	}
+	return command, username, args
+}
+
+// Regexp returns a Filter matching a message whose text or caption matches re.
+// The message is the effective message of the update, see Update.EffectiveMessage.
+func Regexp(re *regexp.Regexp) Filter {
+	return func(update Update) bool {
+		message := update.EffectiveMessage()
+		if message == nil || update.CallbackQuery != nil {
+			return false
+		}
+		if message.Text != "" {
+			return re.MatchString(message.Text)
+		}
+		return message.Caption != "" && re.MatchString(message.Caption)
+	}
+}
+
+// CallbackData returns a Filter matching a callback query whose data starts with prefix.
+func CallbackData(prefix string) Filter {
+	return func(update Update) bool {
+		return update.CallbackQuery != nil && strings.HasPrefix(update.CallbackQuery.Data, prefix)
+	}
+}
+
+// ChatType returns a Filter matching an update from a chat of one of the types, such as ChatTypePrivate.
+func ChatType(types ...string) Filter {
+	return func(update Update) bool {
+		chat := update.Chat()
+		if chat == nil {
+			return false
+		}
+		for _, t := range types {
+			if chat.Type == t {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// HasPhoto is a Filter matching a message with a photo.
+func HasPhoto(update Update) bool {
+	return hasContent(update, func(message *Message) bool { return len(message.Photo) > 0 })
+}
+
+// HasDocument is a Filter matching a message with a document.
+func HasDocument(update Update) bool {
+	return hasContent(update, func(message *Message) bool { return message.Document != nil })
+}
+
+// HasLocation is a Filter matching a message with a location.
+func HasLocation(update Update) bool {
+	return hasContent(update, func(message *Message) bool { return message.Location != nil })
+}
+
+// HasSuccessfulPayment is a Filter matching a service message about a successful payment.
+func HasSuccessfulPayment(update Update) bool {
+	return hasContent(update, func(message *Message) bool { return message.SuccessfulPayment != nil })
+}
+
+// hasContent reports whether the message of the update, not of a callback query, has the content.
+func hasContent(update Update, has func(message *Message) bool) bool {
+	if update.CallbackQuery != nil {
+		return false
+	}
+	message := update.EffectiveMessage()
+	return message != nil && has(message)
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"mime"
 	"net/http"
 	"net/url"
 	"os"
@@ -161,7 +162,7 @@ func (f roundTripperTestFunc) RoundTrip(r *http.Request) (*http.Response, error)
 	var (
 		urlPath    = r.URL.Path
 		methodName = path.Base(urlPath)
-		params     = r.URL.Query()
+		params     = requestParams(r)
 		token      = strings.TrimPrefix(strings.TrimSuffix(urlPath, "/"+methodName), "/bot")
 	)
 
@@ -176,6 +177,69 @@ func (f roundTripperTestFunc) RoundTrip(r *http.Request) (*http.Response, error)
 	return f(methodName, params), nil
 }
 
+// requestParams returns the params sent in the request body.
+// A JSON value that's not a string is represented by its encoding
+// and an uploaded file is represented by its file name followed by its content, separated by a colon.
+func requestParams(r *http.Request) url.Values {
+	params := r.URL.Query()
+	if r.Body == nil {
+		return params
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "multipart/form-data":
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			panic(err)
+		}
+		for field, values := range r.MultipartForm.Value {
+			params[field] = values
+		}
+		for field, files := range r.MultipartForm.File {
+			for _, fh := range files {
+				f, err := fh.Open()
+				if err != nil {
+					panic(err)
+				}
+				b, err := ioutil.ReadAll(f)
+				f.Close()
+				if err != nil {
+					panic(err)
+				}
+				params.Add(field, fh.Filename+":"+string(b))
+			}
+		}
+
+	case "application/json":
+		var object map[string]json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&object); err != nil {
+			panic(err)
+		}
+		for field, raw := range object {
+			var s string
+			if err := json.Unmarshal(raw, &s); err != nil {
+				s = string(raw)
+			}
+			params.Set(field, s)
+		}
+
+	case "application/x-www-form-urlencoded":
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			panic(err)
+		}
+		values, err := url.ParseQuery(string(b))
+		if err != nil {
+			panic(err)
+		}
+		for field, v := range values {
+			params[field] = v
+		}
+	}
+
+	return params
+}
+
 // newTestClient returns an http client for test.
 func newTestClient(fn roundTripperTestFunc) *http.Client {
 	return &http.Client{
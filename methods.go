@@ -1,6 +1,15 @@
 package telegram
 
-import "encoding/json"
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+)
 
 // GetMe returns basic information about the bot.
 // It's a simple method for testing your bot's auth token.
@@ -8,37 +17,449 @@ import "encoding/json"
 //
 // https://core.telegram.org/bots/api#getme.
 func (bot *Bot) GetMe() (User, error) {
-	resp, err := bot.MakeRequest("getMe", nil)
+	return bot.GetMeContext(context.Background())
+}
+
+// GetMeContext is like GetMe but with a context.
+func (bot *Bot) GetMeContext(ctx context.Context) (User, error) {
+	resp, err := bot.MakeRequestContext(ctx, "getMe", nil)
 	if err != nil {
 		return User{}, err
 	}
 
 	var user User
-	if err := json.NewDecoder(resp).Decode(&user); err != nil {
+	if err := resp.decode(&user); err != nil {
 		return User{}, err
 	}
-	bot.User = user
+	bot.setUser(user)
 
-	return bot.User, nil
+	return user, nil
 }
 
 // SendMessage sends text messages. On success, the sent Message is returned.
 //
-//  TODO: Params: SetParseMode, SetEntities, SetDisableWebPagePreview, SetDisableNotification, SetReplyToMessageID, SetAllowSendingWithoutReply, SetReplyMarkup
+//  Params: SetParseMode, SetDisableNotification, SetReplyToMessageID, SetReplyMarkup.
+//  TODO: Params: SetEntities, SetDisableWebPagePreview, SetAllowSendingWithoutReply
 //
 // https://core.telegram.org/bots/api#sendmessage
 func (bot *Bot) SendMessage(chatID int, text string, params ...Param) (Message, error) {
-	params = append(params, setParamInt("chat_id", chatID), setParamString("text", text))
-	urlVal := resolveParam(params)
-	resp, err := bot.MakeRequest("sendMessage", urlVal)
+	return bot.SendMessageContext(context.Background(), chatID, text, params...)
+}
+
+// SendMessageContext is like SendMessage but with a context.
+func (bot *Bot) SendMessageContext(ctx context.Context, chatID int, text string, params ...Param) (Message, error) {
+	call := SendMessageCall(chatID, text, params...)
+	resp, err := bot.makeRequest(ctx, call.Method, resolveParam(call.Params))
 	if err != nil {
 		return Message{}, err
 	}
 
 	var message Message
-	if err := json.NewDecoder(resp).Decode(&message); err != nil {
+	if err := resp.decode(&message); err != nil {
 		return Message{}, err
 	}
 
 	return message, nil
 }
+
+// SendMessageCall returns the Call of SendMessage, to be made in reply to a webhook update.
+func SendMessageCall(chatID int, text string, params ...Param) *Call {
+	return NewCall("sendMessage", append(params, SetChatID(chatID), setParamString("text", text))...)
+}
+
+// SendPhoto sends a photo. On success, the sent Message is returned.
+// The photo must be at most 10 MB, its width and height must not exceed 10000 in total.
+//
+//  Params: SetCaption, SetParseMode, SetDisableNotification, SetReplyToMessageID, SetReplyMarkup.
+//
+// https://core.telegram.org/bots/api#sendphoto
+func (bot *Bot) SendPhoto(chatID int, photo InputFile, params ...Param) (Message, error) {
+	return bot.SendPhotoContext(context.Background(), chatID, photo, params...)
+}
+
+// SendPhotoContext is like SendPhoto but with a context.
+func (bot *Bot) SendPhotoContext(ctx context.Context, chatID int, photo InputFile, params ...Param) (Message, error) {
+	return bot.sendMedia(ctx, "sendPhoto", chatID, "photo", photo, params)
+}
+
+// SendAudio sends an audio file to be displayed in the music player, in the .MP3 or .M4A format. On success, the sent Message is returned.
+// For a voice message use SendVoice instead.
+//
+//  Params: SetCaption, SetParseMode, SetDuration, SetPerformer, SetTitle, SetThumb, SetDisableNotification, SetReplyToMessageID, SetReplyMarkup.
+//
+// https://core.telegram.org/bots/api#sendaudio
+func (bot *Bot) SendAudio(chatID int, audio InputFile, params ...Param) (Message, error) {
+	return bot.SendAudioContext(context.Background(), chatID, audio, params...)
+}
+
+// SendAudioContext is like SendAudio but with a context.
+func (bot *Bot) SendAudioContext(ctx context.Context, chatID int, audio InputFile, params ...Param) (Message, error) {
+	return bot.sendMedia(ctx, "sendAudio", chatID, "audio", audio, params)
+}
+
+// SendDocument sends a general file. On success, the sent Message is returned.
+//
+//  Params: SetThumb, SetCaption, SetParseMode, SetDisableNotification, SetReplyToMessageID, SetReplyMarkup.
+//
+// https://core.telegram.org/bots/api#senddocument
+func (bot *Bot) SendDocument(chatID int, document InputFile, params ...Param) (Message, error) {
+	return bot.SendDocumentContext(context.Background(), chatID, document, params...)
+}
+
+// SendDocumentContext is like SendDocument but with a context.
+func (bot *Bot) SendDocumentContext(ctx context.Context, chatID int, document InputFile, params ...Param) (Message, error) {
+	return bot.sendMedia(ctx, "sendDocument", chatID, "document", document, params)
+}
+
+// SendVideo sends an MPEG4 video, other formats may be sent as a document. On success, the sent Message is returned.
+//
+//  Params: SetDuration, SetWidth, SetHeight, SetThumb, SetCaption, SetParseMode, SetSupportsStreaming, SetDisableNotification, SetReplyToMessageID, SetReplyMarkup.
+//
+// https://core.telegram.org/bots/api#sendvideo
+func (bot *Bot) SendVideo(chatID int, video InputFile, params ...Param) (Message, error) {
+	return bot.SendVideoContext(context.Background(), chatID, video, params...)
+}
+
+// SendVideoContext is like SendVideo but with a context.
+func (bot *Bot) SendVideoContext(ctx context.Context, chatID int, video InputFile, params ...Param) (Message, error) {
+	return bot.sendMedia(ctx, "sendVideo", chatID, "video", video, params)
+}
+
+// SendAnimation sends an animation, a GIF or an H.264/MPEG-4 AVC video without sound. On success, the sent Message is returned.
+//
+//  Params: SetDuration, SetWidth, SetHeight, SetThumb, SetCaption, SetParseMode, SetDisableNotification, SetReplyToMessageID, SetReplyMarkup.
+//
+// https://core.telegram.org/bots/api#sendanimation
+func (bot *Bot) SendAnimation(chatID int, animation InputFile, params ...Param) (Message, error) {
+	return bot.SendAnimationContext(context.Background(), chatID, animation, params...)
+}
+
+// SendAnimationContext is like SendAnimation but with a context.
+func (bot *Bot) SendAnimationContext(ctx context.Context, chatID int, animation InputFile, params ...Param) (Message, error) {
+	return bot.sendMedia(ctx, "sendAnimation", chatID, "animation", animation, params)
+}
+
+// SendVoice sends an audio file to be displayed as a playable voice message, in the .OGG format encoded with OPUS. On success, the sent Message is returned.
+//
+//  Params: SetCaption, SetParseMode, SetDuration, SetDisableNotification, SetReplyToMessageID, SetReplyMarkup.
+//
+// https://core.telegram.org/bots/api#sendvoice
+func (bot *Bot) SendVoice(chatID int, voice InputFile, params ...Param) (Message, error) {
+	return bot.SendVoiceContext(context.Background(), chatID, voice, params...)
+}
+
+// SendVoiceContext is like SendVoice but with a context.
+func (bot *Bot) SendVoiceContext(ctx context.Context, chatID int, voice InputFile, params ...Param) (Message, error) {
+	return bot.sendMedia(ctx, "sendVoice", chatID, "voice", voice, params)
+}
+
+// SendVideoNote sends a rounded square MPEG4 video of up to 1 minute. On success, the sent Message is returned.
+// A video note can't be sent by URL.
+//
+//  Params: SetDuration, SetLength, SetThumb, SetDisableNotification, SetReplyToMessageID, SetReplyMarkup.
+//
+// https://core.telegram.org/bots/api#sendvideonote
+func (bot *Bot) SendVideoNote(chatID int, videoNote InputFile, params ...Param) (Message, error) {
+	return bot.SendVideoNoteContext(context.Background(), chatID, videoNote, params...)
+}
+
+// SendVideoNoteContext is like SendVideoNote but with a context.
+func (bot *Bot) SendVideoNoteContext(ctx context.Context, chatID int, videoNote InputFile, params ...Param) (Message, error) {
+	return bot.sendMedia(ctx, "sendVideoNote", chatID, "video_note", videoNote, params)
+}
+
+// sendMedia sends the file of a media message in the field, and returns the sent Message.
+func (bot *Bot) sendMedia(ctx context.Context, methodName string, chatID int, field string, file InputFile, params []Param) (Message, error) {
+	params = append(params[:len(params):len(params)], setParamInt("chat_id", chatID), setParamFile(field, file))
+	resp, err := bot.makeRequest(ctx, methodName, resolveParam(params))
+	if err != nil {
+		return Message{}, err
+	}
+
+	var message Message
+	if err := resp.decode(&message); err != nil {
+		return Message{}, err
+	}
+
+	return message, nil
+}
+
+// Limits of the number of media in a media group, see SendMediaGroup.
+const (
+	MinMediaGroupSize = 2
+	MaxMediaGroupSize = 10
+)
+
+// SendMediaGroup sends a group of photos, videos, documents or audios as an album.
+// Documents and audio files can be only grouped in an album with messages of the same type,
+// photos and videos can be mixed. Animations can't be sent in an album.
+// On success, the sent messages are returned.
+// A group of less than 2 or more than 10 media, or of media that can't be mixed,
+// is rejected with ErrInvalidMediaGroup without making any request.
+//
+//  Params: SetDisableNotification, SetReplyToMessageID.
+//
+// https://core.telegram.org/bots/api#sendmediagroup
+func (bot *Bot) SendMediaGroup(chatID int, media []InputMedia, params ...Param) ([]Message, error) {
+	return bot.SendMediaGroupContext(context.Background(), chatID, media, params...)
+}
+
+// SendMediaGroupContext is like SendMediaGroup but with a context.
+func (bot *Bot) SendMediaGroupContext(ctx context.Context, chatID int, media []InputMedia, params ...Param) ([]Message, error) {
+	if err := checkMediaGroup(media); err != nil {
+		return nil, err
+	}
+
+	p := resolveParam(params)
+	p.setJSON("chat_id", strconv.Itoa(chatID))
+
+	group := make([]json.RawMessage, len(media))
+	for i, m := range media {
+		var err error
+		if group[i], err = m.encodeMedia(p, "media"+strconv.Itoa(i)); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.SetJSON("media", group); err != nil {
+		return nil, err
+	}
+
+	resp, err := bot.makeRequest(ctx, "sendMediaGroup", p)
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []Message
+	if err := resp.decode(&messages); err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// checkMediaGroup returns an error wrapping ErrInvalidMediaGroup if the media can't be sent as a group.
+func checkMediaGroup(media []InputMedia) error {
+	if len(media) < MinMediaGroupSize || len(media) > MaxMediaGroupSize {
+		return fmt.Errorf("%w: %d media, a group has %d to %d", ErrInvalidMediaGroup, len(media), MinMediaGroupSize, MaxMediaGroupSize)
+	}
+
+	// kind groups the types of media that can be mixed.
+	kind := func(mediaType string) string {
+		if mediaType == "video" {
+			return "photo"
+		}
+		return mediaType
+	}
+
+	first := media[0].mediaType()
+	for _, m := range media {
+		mediaType := m.mediaType()
+		if mediaType == "animation" {
+			return fmt.Errorf("%w: an animation can't be sent in a group", ErrInvalidMediaGroup)
+		}
+		if kind(mediaType) != kind(first) {
+			return fmt.Errorf("%w: %s and %s can't be mixed", ErrInvalidMediaGroup, first, mediaType)
+		}
+	}
+	return nil
+}
+
+// ForwardMessage forwards a message of any kind from the chat fromChatID to the chat chatID.
+// Service messages can't be forwarded. On success, the sent Message is returned.
+//
+//  Params: SetDisableNotification.
+//
+// https://core.telegram.org/bots/api#forwardmessage
+func (bot *Bot) ForwardMessage(chatID, fromChatID, messageID int, params ...Param) (Message, error) {
+	return bot.ForwardMessageContext(context.Background(), chatID, fromChatID, messageID, params...)
+}
+
+// ForwardMessageContext is like ForwardMessage but with a context.
+func (bot *Bot) ForwardMessageContext(ctx context.Context, chatID, fromChatID, messageID int, params ...Param) (Message, error) {
+	params = append(params[:len(params):len(params)],
+		setParamInt("chat_id", chatID),
+		setParamInt("from_chat_id", fromChatID),
+		setParamInt("message_id", messageID),
+	)
+	resp, err := bot.makeRequest(ctx, "forwardMessage", resolveParam(params))
+	if err != nil {
+		return Message{}, err
+	}
+
+	var message Message
+	if err := resp.decode(&message); err != nil {
+		return Message{}, err
+	}
+
+	return message, nil
+}
+
+// CopyMessage copies a message of any kind from the chat fromChatID to the chat chatID.
+// Unlike ForwardMessage, the copy has no link to the original message, and its caption may be replaced with SetCaption.
+// Service messages and invoice messages can't be copied. On success, the MessageID of the sent message is returned.
+//
+//  Params: SetCaption, SetParseMode, SetDisableNotification, SetReplyToMessageID, SetReplyMarkup.
+//
+// https://core.telegram.org/bots/api#copymessage
+func (bot *Bot) CopyMessage(chatID, fromChatID, messageID int, params ...Param) (MessageID, error) {
+	return bot.CopyMessageContext(context.Background(), chatID, fromChatID, messageID, params...)
+}
+
+// CopyMessageContext is like CopyMessage but with a context.
+func (bot *Bot) CopyMessageContext(ctx context.Context, chatID, fromChatID, messageID int, params ...Param) (MessageID, error) {
+	params = append(params[:len(params):len(params)],
+		setParamInt("chat_id", chatID),
+		setParamInt("from_chat_id", fromChatID),
+		setParamInt("message_id", messageID),
+	)
+	resp, err := bot.makeRequest(ctx, "copyMessage", resolveParam(params))
+	if err != nil {
+		return MessageID{}, err
+	}
+
+	var id MessageID
+	if err := resp.decode(&id); err != nil {
+		return MessageID{}, err
+	}
+
+	return id, nil
+}
+
+// DeleteMessage deletes a message, including service messages. Returns True on success.
+// A message can only be deleted if it was sent less than 48 hours ago, and other restrictions apply,
+// see the Telegram API documentation.
+//
+// https://core.telegram.org/bots/api#deletemessage
+func (bot *Bot) DeleteMessage(chatID, messageID int) (bool, error) {
+	return bot.DeleteMessageContext(context.Background(), chatID, messageID)
+}
+
+// DeleteMessageContext is like DeleteMessage but with a context.
+func (bot *Bot) DeleteMessageContext(ctx context.Context, chatID, messageID int) (bool, error) {
+	params := resolveParam([]Param{setParamInt("chat_id", chatID), setParamInt("message_id", messageID)})
+	resp, err := bot.makeRequest(ctx, "deleteMessage", params)
+	if err != nil {
+		return false, err
+	}
+
+	var ok bool
+	if err := resp.decode(&ok); err != nil {
+		return false, err
+	}
+
+	return ok, nil
+}
+
+// Size limits of the files sent to and downloaded from the Telegram API.
+// They're lifted when the bot uses a local Bot API server, see SetLocalMode.
+const (
+	MaxUploadSize   = 50 << 20 // 50 MB
+	MaxDownloadSize = 20 << 20 // 20 MB
+)
+
+// GetFile returns basic info about a file and prepare it for downloading.
+// The file can then be downloaded with DownloadFile.
+//
+// https://core.telegram.org/bots/api#getfile
+func (bot *Bot) GetFile(fileID string) (File, error) {
+	return bot.GetFileContext(context.Background(), fileID)
+}
+
+// GetFileContext is like GetFile but with a context.
+func (bot *Bot) GetFileContext(ctx context.Context, fileID string) (File, error) {
+	p := resolveParam([]Param{setParamString("file_id", fileID)})
+	resp, err := bot.makeRequest(ctx, "getFile", p)
+	if err != nil {
+		return File{}, err
+	}
+
+	var file File
+	if err := resp.decode(&file); err != nil {
+		return File{}, err
+	}
+
+	return file, nil
+}
+
+// DownloadFile returns the content of a file returned by GetFile. The caller must close the content.
+// A file larger than MaxDownloadSize can't be downloaded unless the bot uses a local Bot API server,
+// which returns an absolute file path to read the file from the disk.
+func (bot *Bot) DownloadFile(file File) (io.ReadCloser, error) {
+	return bot.DownloadFileContext(context.Background(), file)
+}
+
+// DownloadFileContext is like DownloadFile but with a context.
+func (bot *Bot) DownloadFileContext(ctx context.Context, file File) (io.ReadCloser, error) {
+	if bot.localMode && filepath.IsAbs(file.FilePath) {
+		return os.Open(file.FilePath)
+	}
+	if !bot.localMode && file.FileSize > MaxDownloadSize {
+		return nil, fmt.Errorf("%w: %q is %d bytes, the maximum is %d bytes", ErrFileTooLarge, file.FilePath, file.FileSize, MaxDownloadSize)
+	}
+
+	endpoint := fmt.Sprintf("%s/file/bot%s/%s", bot.hostURL, bot.token, file.FilePath)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := bot.client.Do(req)
+	if err != nil {
+		return nil, bot.networkError("downloadFile", err)
+	}
+	if w.StatusCode != http.StatusOK {
+		w.Body.Close()
+		return nil, &BotError{Code: w.StatusCode, Description: http.StatusText(w.StatusCode)}
+	}
+
+	return w.Body, nil
+}
+
+// LogOut logs out from the cloud Bot API server before launching the bot locally.
+// You must log out the bot before running it locally, otherwise there is no guarantee that the bot will receive updates.
+// After a successful call, you can immediately log in on a local server,
+// but will not be able to log in back to the cloud Bot API server for 10 minutes. Returns True on success.
+//
+// https://core.telegram.org/bots/api#logout
+func (bot *Bot) LogOut() (bool, error) {
+	return bot.LogOutContext(context.Background())
+}
+
+// LogOutContext is like LogOut but with a context.
+func (bot *Bot) LogOutContext(ctx context.Context) (bool, error) {
+	resp, err := bot.MakeRequestContext(ctx, "logOut", nil)
+	if err != nil {
+		return false, err
+	}
+
+	var ok bool
+	if err := resp.decode(&ok); err != nil {
+		return false, err
+	}
+
+	return ok, nil
+}
+
+// Close closes the bot instance before moving it from one local server to another.
+// You need to delete the webhook before calling this method to ensure that the bot isn't launched again after server restart.
+// The method will return error 429 in the first 10 minutes after the bot is launched. Returns True on success.
+//
+// https://core.telegram.org/bots/api#close
+func (bot *Bot) Close() (bool, error) {
+	return bot.CloseContext(context.Background())
+}
+
+// CloseContext is like Close but with a context.
+func (bot *Bot) CloseContext(ctx context.Context) (bool, error) {
+	resp, err := bot.MakeRequestContext(ctx, "close", nil)
+	if err != nil {
+		return false, err
+	}
+
+	var ok bool
+	if err := resp.decode(&ok); err != nil {
+		return false, err
+	}
+
+	return ok, nil
+}
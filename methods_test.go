@@ -1,6 +1,14 @@
 package telegram_test
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
 	"github.com/62bot/telegram"
 	"github.com/billyzaelani/is"
 )
@@ -22,3 +30,351 @@ func sendMessageOK(is *is.Is, bot *telegram.Bot) {
 	is.Equal(message.Chat.ID, wantChatID)
 	is.Equal(message.Text, wantText)
 }
+
+func getFileOK(is *is.Is, bot *telegram.Bot) {
+	file, err := bot.GetFile("AgADBAADbK4xG2")
+	is.NoError(err)
+
+	is.Equal(file.FileID, "AgADBAADbK4xG2")
+	is.Equal(file.FileSize, 12345)
+	is.Equal(file.FilePath, "photos/file_0.jpg")
+}
+
+func logOutOK(is *is.Is, bot *telegram.Bot) {
+	ok, err := bot.LogOut()
+	is.NoError(err)
+
+	is.True(ok) // logOut should be ok
+}
+
+func closeOK(is *is.Is, bot *telegram.Bot) {
+	ok, err := bot.Close()
+	is.NoError(err)
+
+	is.True(ok) // close should be ok
+}
+
+func closeTooEarly(is *is.Is, bot *telegram.Bot) {
+	_, err := bot.Close()
+	is.Error(err, telegram.ErrTooManyRequests)
+}
+
+func sendPhotoOK(is *is.Is, bot *telegram.Bot) {
+	markup := &telegram.InlineKeyboardMarkup{
+		InlineKeyboard: [][]*telegram.InlineKeyboardButton{{{Text: "like", CallbackData: "like"}}},
+	}
+
+	message, err := bot.SendPhoto(12345, telegram.InputFileID("photo-id"),
+		telegram.SetCaption("*hello*"),
+		telegram.SetParseMode(telegram.ParseModeMarkdownV2),
+		telegram.SetReplyMarkup(markup),
+	)
+	is.NoError(err)
+
+	is.Equal(len(message.Photo), 2)
+	is.Equal(message.Photo[1].FileID, "photo-big")
+	is.Equal(message.Caption, "hello")
+}
+
+func sendPhotoUpload(is *is.Is, bot *telegram.Bot) {
+	message, err := bot.SendPhoto(12345, telegram.InputFileReader("photo.jpg", strings.NewReader("photo content")))
+	is.NoError(err)
+
+	is.Equal(len(message.Photo), 2)
+}
+
+func sendPhotoErrorWrongFileID(is *is.Is, bot *telegram.Bot) {
+	_, err := bot.SendPhoto(12345, telegram.InputFileID("unknown"))
+	var botError *telegram.BotError
+	is.ErrorAs(err, &botError)
+	is.Equal(botError.Code, http.StatusBadRequest)
+}
+
+func sendAudioOK(is *is.Is, bot *telegram.Bot) {
+	message, err := bot.SendAudio(12345, telegram.InputFileURL("https://example.com/song.mp3"),
+		telegram.SetDuration(180),
+		telegram.SetPerformer("62Bot"),
+		telegram.SetTitle("Song"),
+	)
+	is.NoError(err)
+
+	is.Equal(message.Audio.FileID, "audio-id")
+	is.Equal(message.Audio.Duration, 180)
+}
+
+func sendDocumentWithThumb(is *is.Is, bot *telegram.Bot) {
+	message, err := bot.SendDocument(12345, telegram.InputFileReader("report.pdf", strings.NewReader("document content")),
+		telegram.SetThumb(telegram.InputFileReader("thumb.jpg", strings.NewReader("thumb content"))),
+		telegram.SetCaption("report"),
+	)
+	is.NoError(err)
+
+	is.Equal(message.Document.FileName, "report.pdf")
+	is.Equal(message.Document.Thumb.FileID, "thumb-id")
+}
+
+func sendVideoOK(is *is.Is, bot *telegram.Bot) {
+	message, err := bot.SendVideo(12345, telegram.InputFileID("video-id"),
+		telegram.SetDuration(10),
+		telegram.SetWidth(1280),
+		telegram.SetHeight(720),
+		telegram.SetSupportsStreaming(true),
+	)
+	is.NoError(err)
+
+	is.Equal(message.Video.Width, 1280)
+	is.Equal(message.Video.Height, 720)
+}
+
+func sendAnimationOK(is *is.Is, bot *telegram.Bot) {
+	message, err := bot.SendAnimation(12345, telegram.InputFileID("animation-id"),
+		telegram.SetDisableNotification(true),
+	)
+	is.NoError(err)
+
+	is.Equal(message.Animation.FileID, "animation-id")
+}
+
+func sendVoiceOK(is *is.Is, bot *telegram.Bot) {
+	message, err := bot.SendVoice(12345, telegram.InputFileReader("voice.ogg", strings.NewReader("voice content")),
+		telegram.SetDuration(5),
+		telegram.SetReplyToMessageID(7),
+	)
+	is.NoError(err)
+
+	is.Equal(message.Voice.Duration, 5)
+}
+
+func sendVideoNoteOK(is *is.Is, bot *telegram.Bot) {
+	message, err := bot.SendVideoNote(12345, telegram.InputFileReader("note.mp4", strings.NewReader("video note content")),
+		telegram.SetLength(240),
+		telegram.SetDuration(8),
+	)
+	is.NoError(err)
+
+	is.Equal(message.VideoNote.Length, 240)
+}
+
+func sendMediaGroupOK(is *is.Is, bot *telegram.Bot) {
+	messages, err := bot.SendMediaGroup(12345, []telegram.InputMedia{
+		telegram.InputMediaPhoto{Media: telegram.InputFileID("photo-id"), Caption: "first"},
+		telegram.InputMediaVideo{
+			Media:             telegram.InputFileReader("video.mp4", strings.NewReader("video content")),
+			Thumb:             telegram.InputFileReader("thumb.jpg", strings.NewReader("thumb content")),
+			SupportsStreaming: true,
+		},
+	}, telegram.SetDisableNotification(true))
+	is.NoError(err)
+
+	is.Equal(len(messages), 2)
+	is.Equal(messages[0].MediaGroupID, "42")
+	is.Equal(messages[1].Video.FileID, "video-id")
+}
+
+// TestSendMediaGroupInvalid tests that invalid media groups are rejected without making any request.
+func TestSendMediaGroupInvalid(t *testing.T) {
+	photo := telegram.InputMediaPhoto{Media: telegram.InputFileID("photo-id")}
+	video := telegram.InputMediaVideo{Media: telegram.InputFileID("video-id")}
+	document := telegram.InputMediaDocument{Media: telegram.InputFileID("document-id")}
+	audio := telegram.InputMediaAudio{Media: telegram.InputFileID("audio-id")}
+	animation := telegram.InputMediaAnimation{Media: telegram.InputFileID("animation-id")}
+
+	errMissingMedia := errors.New("missing media")
+
+	eleven := make([]telegram.InputMedia, 11)
+	for i := range eleven {
+		eleven[i] = photo
+	}
+
+	tests := map[string]struct {
+		media   []telegram.InputMedia
+		wantErr error
+	}{
+		"too_few":            {media: []telegram.InputMedia{photo}, wantErr: telegram.ErrInvalidMediaGroup},
+		"too_many":           {media: eleven, wantErr: telegram.ErrInvalidMediaGroup},
+		"document_and_photo": {media: []telegram.InputMedia{document, photo}, wantErr: telegram.ErrInvalidMediaGroup},
+		"audio_and_document": {media: []telegram.InputMedia{audio, document}, wantErr: telegram.ErrInvalidMediaGroup},
+		"animations":         {media: []telegram.InputMedia{animation, animation}, wantErr: telegram.ErrInvalidMediaGroup},
+		"photo_and_video":    {media: []telegram.InputMedia{photo, video}},
+		"documents":          {media: []telegram.InputMedia{document, document}},
+		"audios":             {media: []telegram.InputMedia{audio, audio}},
+		"missing_media":      {media: []telegram.InputMedia{photo, telegram.InputMediaPhoto{}}, wantErr: errMissingMedia},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			is := is.New(t)
+
+			requests := 0
+			client := newTestClient(func(methodName string, params url.Values) *http.Response {
+				requests++
+				return newHTTPResponse(http.StatusOK, []byte(`{"ok":true,"result":[]}`))
+			})
+			bot, err := telegram.NewBot(validTestToken, telegram.SetClient(client), telegram.SetLazy())
+			is.NoError(err)
+
+			_, err = bot.SendMediaGroup(12345, tt.media)
+			switch tt.wantErr {
+			case nil:
+				is.NoError(err)
+				is.Equal(requests, 1)
+			case errMissingMedia:
+				is.Error(err) // media without a file
+				is.Equal(requests, 0)
+			default:
+				is.Error(err, tt.wantErr)
+				is.Equal(requests, 0)
+			}
+		})
+	}
+}
+
+func editMessageTextOK(is *is.Is, bot *telegram.Bot) {
+	result, err := bot.EditMessageText(telegram.ChatMessage(12345, 7), "done",
+		telegram.SetParseMode(telegram.ParseModeHTML),
+	)
+	is.NoError(err)
+
+	is.True(!result.NotModified)
+	is.Equal(result.Message.Text, "done")
+}
+
+func editMessageTextInline(is *is.Is, bot *telegram.Bot) {
+	query := &telegram.CallbackQuery{InlineMessageID: "inline-id"}
+	result, err := bot.EditMessageText(query.EditTarget(), "done")
+	is.NoError(err)
+
+	is.Equal(result, telegram.EditResult{}) // inline message edited
+}
+
+func editMessageTextNotModified(is *is.Is, bot *telegram.Bot) {
+	result, err := bot.EditMessageText(telegram.ChatMessage(12345, 7), "done")
+	is.NoError(err)
+
+	is.True(result.NotModified)
+	is.True(result.Message == nil)
+}
+
+func editMessageCaptionOK(is *is.Is, bot *telegram.Bot) {
+	query := &telegram.CallbackQuery{Message: &telegram.Message{MessageID: 7, Chat: &telegram.Chat{ID: 12345}}}
+	result, err := bot.EditMessageCaption(query.EditTarget(), "new caption")
+	is.NoError(err)
+
+	is.Equal(result.Message.Caption, "new caption")
+}
+
+func editMessageMediaOK(is *is.Is, bot *telegram.Bot) {
+	result, err := bot.EditMessageMedia(telegram.ChatMessage(12345, 7), telegram.InputMediaPhoto{
+		Media:   telegram.InputFileReader("photo.jpg", strings.NewReader("photo content")),
+		Caption: "new",
+	})
+	is.NoError(err)
+
+	is.Equal(result.Message.Photo[0].FileID, "new-photo-id")
+}
+
+func editMessageReplyMarkupRemove(is *is.Is, bot *telegram.Bot) {
+	result, err := bot.EditMessageReplyMarkup(telegram.InlineMessage("inline-id"), nil)
+	is.NoError(err)
+
+	is.Equal(result, telegram.EditResult{})
+}
+
+func forwardMessageOK(is *is.Is, bot *telegram.Bot) {
+	message, err := bot.ForwardMessage(-1001234, 12345, 7, telegram.SetDisableNotification(true))
+	is.NoError(err)
+
+	is.Equal(message.MessageID, 70)
+	is.Equal(message.ForwardFrom.ID, 12345)
+}
+
+func copyMessageOK(is *is.Is, bot *telegram.Bot) {
+	id, err := bot.CopyMessage(-1001234, 12345, 7, telegram.SetCaption("new caption"))
+	is.NoError(err)
+
+	is.Equal(id, telegram.MessageID{MessageID: 71})
+}
+
+func deleteMessageOK(is *is.Is, bot *telegram.Bot) {
+	ok, err := bot.DeleteMessage(12345, 7)
+	is.NoError(err)
+
+	is.True(ok)
+}
+
+func deleteMessageNotFound(is *is.Is, bot *telegram.Bot) {
+	_, err := bot.DeleteMessage(12345, 8)
+	var botError *telegram.BotError
+	is.ErrorAs(err, &botError)
+	is.Equal(botError.Code, http.StatusBadRequest)
+}
+
+// TestBulkMethods tests retrying the messages hitting the flood limits and collecting the failed ones.
+func TestBulkMethods(t *testing.T) {
+	is := is.New(t)
+
+	var calls []string
+	limited := false
+	client := newTestClient(func(methodName string, params url.Values) *http.Response {
+		messageID := params.Get("message_id")
+		calls = append(calls, methodName+" "+messageID)
+
+		switch {
+		case messageID == "2" && !limited:
+			limited = true
+			return newHTTPResponse(http.StatusTooManyRequests, []byte(`{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 1","parameters":{"retry_after":1}}`))
+		case messageID == "3":
+			return newHTTPResponse(http.StatusBadRequest, []byte(`{"ok":false,"error_code":400,"description":"Bad Request: message to delete not found"}`))
+		case methodName == "copyMessage":
+			return newHTTPResponse(http.StatusOK, []byte(`{"ok":true,"result":{"message_id":10`+messageID+`}}`))
+		}
+		return newHTTPResponse(http.StatusOK, []byte(`{"ok":true,"result":true}`))
+	})
+	bot, err := telegram.NewBot(validTestToken, telegram.SetClient(client), telegram.SetLazy())
+	is.NoError(err)
+
+	err = bot.DeleteMessages(12345, []int{1, 2, 3, 4})
+	var bulkError *telegram.BulkError
+	is.ErrorAs(err, &bulkError)
+	is.Equal(bulkError.Method, "deleteMessage")
+	is.Equal(len(bulkError.Errors), 1)
+	is.Equal(bulkError.Errors[0].MessageID, 3)
+	is.Equal(calls, []string{"deleteMessage 1", "deleteMessage 2", "deleteMessage 2", "deleteMessage 3", "deleteMessage 4"})
+
+	ids, err := bot.CopyMessages(-1001234, 12345, []int{1, 4})
+	is.NoError(err)
+	is.Equal(ids, []telegram.MessageID{{MessageID: 101}, {MessageID: 104}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = bot.ForwardMessagesContext(ctx, -1001234, 12345, []int{1, 4})
+	is.Error(err, context.Canceled)
+}
+
+// TestBulkMethodsRateLimits tests that the requests of a bulk method are paced by the rate limits,
+// even for a method not limited otherwise.
+func TestBulkMethodsRateLimits(t *testing.T) {
+	is := is.New(t)
+
+	var deleted []time.Time
+	client := newTestClient(func(methodName string, params url.Values) *http.Response {
+		deleted = append(deleted, time.Now())
+		return newHTTPResponse(http.StatusOK, []byte(`{"ok":true,"result":true}`))
+	})
+	limits := telegram.RateLimits{PrivateChat: telegram.RateLimit{Count: 1, Per: 50 * time.Millisecond}}
+	bot, err := telegram.NewBot(validTestToken, telegram.SetClient(client), telegram.SetLazy(), telegram.SetRateLimits(limits))
+	is.NoError(err)
+
+	start := time.Now()
+	is.NoError(bot.DeleteMessages(12345, []int{1, 2, 3}))
+
+	is.Equal(len(deleted), 3)
+	is.True(deleted[0].Sub(start) < 50*time.Millisecond)   // the first one isn't delayed
+	is.True(deleted[2].Sub(start) >= 100*time.Millisecond) // then one message every period
+
+	deleted = nil
+	start = time.Now()
+	_, err = bot.DeleteMessage(12345, 4)
+	is.NoError(err)
+	is.True(deleted[0].Sub(start) < 50*time.Millisecond) // a single deleteMessage isn't limited
+}
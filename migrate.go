@@ -0,0 +1,110 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+)
+
+// chatMigrator resends the requests failed because the group chat was migrated to a supergroup,
+// and remembers the migrations to send later requests to the supergroup right away.
+type chatMigrator struct {
+	onMigrate func(oldChatID, newChatID int)
+
+	mu    sync.RWMutex
+	chats map[int]int // Old chat id to the new chat id.
+}
+
+func newChatMigrator(onMigrate func(oldChatID, newChatID int)) *chatMigrator {
+	return &chatMigrator{
+		onMigrate: onMigrate,
+		chats:     make(map[int]int),
+	}
+}
+
+// wrap returns an Invoker rewriting the chat_id and from_chat_id params of the requests sent with next.
+func (m *chatMigrator) wrap(next Invoker) Invoker {
+	return func(ctx context.Context, methodName string, params *Params) (*Response, error) {
+		chatID, err := strconv.Atoi(params.Get("chat_id"))
+		params = m.rewriteChats(params)
+		if err != nil {
+			// no chat_id or a @username.
+			return next(ctx, methodName, params)
+		}
+
+		resp, err := next(ctx, methodName, params)
+
+		var migrated *ChatMigratedError
+		if !errors.As(err, &migrated) {
+			return resp, err
+		}
+		if params.Get("from_chat_id") != "" {
+			// the error doesn't tell whether chat_id or from_chat_id was migrated.
+			return resp, err
+		}
+
+		m.record(chatID, migrated.MigrateToChatID)
+		if params.hasReaderUpload() {
+			// the reader is consumed, only the later requests go to the supergroup.
+			return resp, err
+		}
+		return next(ctx, methodName, params.withChatID("chat_id", migrated.MigrateToChatID))
+	}
+}
+
+// rewriteChats returns the params with the chat_id and from_chat_id params replaced by the supergroup
+// they were migrated to, if known.
+func (m *chatMigrator) rewriteChats(params *Params) *Params {
+	return m.rewrite(m.rewrite(params, "chat_id"), "from_chat_id")
+}
+
+// rewrite returns the params with the chat of the field replaced by the supergroup it was migrated to, if known.
+func (m *chatMigrator) rewrite(params *Params, field string) *Params {
+	chatID, err := strconv.Atoi(params.Get(field))
+	if err != nil {
+		return params
+	}
+	if newChatID, ok := m.lookup(chatID); ok {
+		return params.withChatID(field, newChatID)
+	}
+	return params
+}
+
+// observe records the migrations announced by the service messages in updates.
+func (m *chatMigrator) observe(updates []Update) {
+	for _, update := range updates {
+		message := update.Message
+		if message == nil || message.Chat == nil {
+			continue
+		}
+
+		if message.MigrateToChatID != 0 {
+			m.record(message.Chat.ID, message.MigrateToChatID)
+		}
+		if message.MigrateFromChatID != 0 {
+			m.record(message.MigrateFromChatID, message.Chat.ID)
+		}
+	}
+}
+
+// lookup returns the chat id the chat was migrated to.
+func (m *chatMigrator) lookup(chatID int) (int, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	newChatID, ok := m.chats[chatID]
+	return newChatID, ok
+}
+
+// record records the migration and calls the hook if it's a new one.
+func (m *chatMigrator) record(oldChatID, newChatID int) {
+	m.mu.Lock()
+	known := m.chats[oldChatID] == newChatID
+	m.chats[oldChatID] = newChatID
+	m.mu.Unlock()
+
+	if !known && m.onMigrate != nil {
+		m.onMigrate(oldChatID, newChatID)
+	}
+}
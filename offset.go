@@ -0,0 +1,115 @@
+package telegram
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// OffsetStore persists the offset of the updates handled by a Poller, so a restarted Poller
+// neither receives the handled updates again nor loses the others.
+type OffsetStore interface {
+	// Load returns the last saved state, or the zero OffsetState when nothing is saved yet.
+	Load() (OffsetState, error)
+	// Save saves the state, replacing the previous one.
+	Save(state OffsetState) error
+}
+
+// OffsetState is the state of a Poller saved in an OffsetStore.
+type OffsetState struct {
+	Offset int   `json:"offset"`           // Identifier of the next update to receive, 0 before the first update.
+	Recent []int `json:"recent,omitempty"` // Identifiers of the last handled updates, oldest first.
+}
+
+// MemoryOffsetStore is an OffsetStore keeping the state in memory.
+// The zero value is ready to use.
+type MemoryOffsetStore struct {
+	mu    sync.Mutex
+	state OffsetState
+}
+
+// Load returns the last saved state.
+func (s *MemoryOffsetStore) Load() (OffsetState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.state.clone(), nil
+}
+
+// Save saves the state.
+func (s *MemoryOffsetStore) Save(state OffsetState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state = state.clone()
+	return nil
+}
+
+// FileOffsetStore is an OffsetStore keeping the state in a JSON file.
+// The file is replaced atomically, a crash while saving leaves the previous state intact.
+type FileOffsetStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileOffsetStore returns a FileOffsetStore keeping the state in the file at path.
+// The file is created on the first save, its directory must exist.
+func NewFileOffsetStore(path string) *FileOffsetStore {
+	return &FileOffsetStore{path: path}
+}
+
+// Load reads the state from the file, a missing file is the zero OffsetState.
+func (s *FileOffsetStore) Load() (OffsetState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var state OffsetState
+	b, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+
+	err = json.Unmarshal(b, &state)
+	return state, err
+}
+
+// Save writes the state to a temporary file in the directory of the file, then renames it to the file.
+func (s *FileOffsetStore) Save(state OffsetState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) // no-op once renamed.
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), s.path)
+}
+
+func (state OffsetState) clone() OffsetState {
+	state.Recent = append([]int(nil), state.Recent...)
+	return state
+}
@@ -1,6 +1,9 @@
 package telegram
 
-import "net/http"
+import (
+	"io"
+	"net/http"
+)
 
 // TelegramURL is a Telegram Host URL.
 const TelegramURL = "https://api.telegram.org"
@@ -22,6 +25,77 @@ func SetClient(c *http.Client) Option {
 	}
 }
 
+// SetLocalMode returns an option for a bot using a local Bot API server, set with SetHostURL.
+// In local mode files created by InputFileLocal are read by the server from the disk,
+// files returned by GetFile are read from the disk by DownloadFile,
+// and the upload and download size limits of the Telegram API are lifted.
+//
+// https://core.telegram.org/bots/api#using-a-local-bot-api-server
+func SetLocalMode() Option {
+	return func(bot *Bot) {
+		bot.localMode = true
+	}
+}
+
+// SetLazy returns an option to skip the GetMe call of NewBot, so the bot is created without any request.
+// The bot info is fetched on first use by Me.
+func SetLazy() Option {
+	return func(bot *Bot) {
+		bot.lazy = true
+	}
+}
+
+// SetRetryPolicy returns an option to retry failed requests according to the policy.
+func SetRetryPolicy(policy RetryPolicy) Option {
+	return func(bot *Bot) {
+		bot.retryPolicy = &policy
+	}
+}
+
+// SetRateLimits returns an option to delay requests to chats, so they are kept within the limits.
+// The requests are limited by their chat_id param, requests without one are never delayed.
+// Only the methods in limits.Methods are limited, by default the methods sending a message.
+func SetRateLimits(limits RateLimits) Option {
+	return func(bot *Bot) {
+		bot.rateLimiter = newRateLimiter(limits)
+	}
+}
+
+// SetChatMigration returns an option to handle the migration of group chats to supergroups.
+// A request failed because its chat was migrated is sent again to the supergroup
+// and the later requests to the old chat are sent to the supergroup right away.
+// The source chat of a forwarded or copied message is rewritten as well once its migration is known,
+// but a forward or a copy failed because of a migration isn't sent again nor remembered,
+// since the error doesn't tell which of the chats was migrated.
+// A request uploading a file from an io.Reader can't be sent again, it fails with the ChatMigratedError
+// but the later requests are sent to the supergroup.
+// The migrations announced in the updates returned by GetUpdates, and in the updates received by
+// a WebhookHandler made with NewWebhookReplyHandler, are remembered as well.
+// A WebhookHandler made with NewWebhookHandler has no bot, the migrations are then only learnt from failed requests.
+// onMigrate, when not nil, is called once for every migration found.
+func SetChatMigration(onMigrate func(oldChatID, newChatID int)) Option {
+	return func(bot *Bot) {
+		bot.chatMigrator = newChatMigrator(onMigrate)
+	}
+}
+
+// SetInterceptors returns an option to add interceptors called around every request made by the bot,
+// including the ones made with MakeRequest. The first interceptor is the outermost one.
+func SetInterceptors(interceptors ...Interceptor) Option {
+	return func(bot *Bot) {
+		bot.interceptors = append(bot.interceptors, interceptors...)
+	}
+}
+
+// SetDryRun returns an option to make a bot that never calls the Telegram API, such as to replay recorded updates.
+// Every request is written to w as a JSON line {"method": ..., params...} and succeeds with a null result,
+// so the methods of the bot return zero values. Interceptors are still called around the requests.
+func SetDryRun(w io.Writer) Option {
+	return func(bot *Bot) {
+		bot.dryRun = &dryRun{w: w}
+	}
+}
+
 // trySetDefaultHostURL sets bot host URL to default if its unset.
 func (bot *Bot) trySetDefaultHostURL() {
 	if bot.hostURL == "" {
@@ -2,40 +2,299 @@ package telegram
 
 import (
 	"encoding/json"
+	"fmt"
+	"net/http"
 	"net/url"
+	"path/filepath"
 	"strconv"
+	"strings"
 )
 
+// Params holds the parameters of a request.
+// Files to upload are kept apart from the url.Values since they are sent as multipart/form-data.
+//
+// Every value is kept as a string in the url.Values. The values set with SetJSON are also marked as JSON,
+// so they're embedded as is when the request is sent with a JSON body instead of being quoted as a string.
+type Params struct {
+	url.Values
+
+	files  map[string]InputFile
+	json   map[string]bool
+	header http.Header
+}
+
+// newParams returns Params holding the url.Values v.
+func newParams(v url.Values) *Params {
+	if v == nil {
+		v = url.Values{}
+	}
+	return &Params{Values: v}
+}
+
+// SetFile sets the field to the file. A file to upload is sent as a part of multipart/form-data request,
+// while a file id or a URL is set as a regular value.
+func (p *Params) SetFile(field string, file InputFile) {
+	if !file.isUpload() {
+		delete(p.files, field)
+		p.Set(field, file.id)
+		return
+	}
+
+	if p.files == nil {
+		p.files = make(map[string]InputFile)
+	}
+	p.Del(field)
+	p.files[field] = file
+}
+
+// attachPrefix is the prefix of the parts of the files referenced as attach://<name>.
+const attachPrefix = "attach_"
+
+// attach returns the reference to the file in a param, its file_id or URL, or attach://<name> when the file
+// is uploaded in its own part, as for a thumbnail or the media of an InputMedia.
+func (p *Params) attach(name string, file InputFile) string {
+	if !file.isUpload() {
+		return file.id
+	}
+
+	name = attachPrefix + name
+	p.SetFile(name, file)
+	return "attach://" + name
+}
+
+// setAttach sets the field to a reference to the file, see attach.
+func (p *Params) setAttach(field string, file InputFile) {
+	p.Set(field, p.attach(field, file))
+}
+
+// SetJSON sets the field to the JSON encoding of v.
+func (p *Params) SetJSON(field string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	p.setJSON(field, string(b))
+	return nil
+}
+
+// setJSON sets the field to the JSON encoded value s.
+func (p *Params) setJSON(field string, s string) {
+	if p.json == nil {
+		p.json = make(map[string]bool)
+	}
+	p.Set(field, s)
+	p.json[field] = true
+}
+
+// SetHeader sets the header of the HTTP request sending the params, such as a tracing header.
+// The Content-Type header is set by the request itself.
+func (p *Params) SetHeader(key, value string) {
+	if p.header == nil {
+		p.header = make(http.Header)
+	}
+	p.header.Set(key, value)
+}
+
+// Header returns the headers set with SetHeader, nil when there's none.
+func (p *Params) Header() http.Header {
+	return p.header
+}
+
+// hasJSON reports whether the params have a value set with SetJSON.
+func (p *Params) hasJSON() bool {
+	return len(p.json) > 0
+}
+
+// encodeJSON returns the JSON encoding of the values.
+func (p *Params) encodeJSON() ([]byte, error) {
+	object, err := p.jsonObject()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(object)
+}
+
+// jsonObject returns the values as the fields of a JSON object. Values marked as JSON are embedded as is
+// as long as they're still a valid JSON, others are encoded as a string.
+func (p *Params) jsonObject() (map[string]json.RawMessage, error) {
+	object := make(map[string]json.RawMessage, len(p.Values))
+	for field := range p.Values {
+		value := p.Get(field)
+		if p.json[field] && json.Valid([]byte(value)) {
+			object[field] = json.RawMessage(value)
+			continue
+		}
+
+		b, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		object[field] = b
+	}
+
+	return object, nil
+}
+
+// hasUpload reports whether the params have a file to upload.
+func (p *Params) hasUpload() bool {
+	return len(p.files) > 0
+}
+
+// hasReaderUpload reports whether the params have a file to upload from an io.Reader,
+// which makes the request impossible to send again.
+func (p *Params) hasReaderUpload() bool {
+	for _, file := range p.files {
+		if file.reader != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// clone returns a copy of the params which can be changed without touching the params,
+// since they may belong to the caller.
+func (p *Params) clone() *Params {
+	values := make(url.Values, len(p.Values))
+	for field, v := range p.Values {
+		values[field] = v
+	}
+
+	clone := &Params{Values: values}
+	for field, file := range p.files {
+		if clone.files == nil {
+			clone.files = make(map[string]InputFile, len(p.files))
+		}
+		clone.files[field] = file
+	}
+	for field := range p.json {
+		if clone.json == nil {
+			clone.json = make(map[string]bool, len(p.json))
+		}
+		clone.json[field] = true
+	}
+	if p.header != nil {
+		clone.header = p.header.Clone()
+	}
+
+	return clone
+}
+
+// withChatID returns a copy of the params with the field, chat_id or from_chat_id, set to chatID.
+func (p *Params) withChatID(field string, chatID int) *Params {
+	clone := p.clone()
+	clone.setJSON(field, strconv.Itoa(chatID))
+	return clone
+}
+
+// withLocalFiles returns a copy of the params where the files created by InputFileLocal
+// are set to their file:// URI, to be read by a local Bot API server.
+func (p *Params) withLocalFiles() (*Params, error) {
+	var clone *Params
+	for field, file := range p.files {
+		// files referenced as attach://<name> stay uploaded, the reference can't be a file:// URI.
+		if !file.local || strings.HasPrefix(field, attachPrefix) {
+			continue
+		}
+
+		path, err := filepath.Abs(file.path)
+		if err != nil {
+			return nil, err
+		}
+
+		if clone == nil {
+			clone = p.clone()
+		}
+		clone.SetFile(field, InputFileURL((&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()))
+	}
+
+	if clone == nil {
+		return p, nil
+	}
+	return clone, nil
+}
+
+// checkUploadSize returns an error wrapping ErrFileTooLarge if a file to upload is larger than max bytes.
+func (p *Params) checkUploadSize(max int64) error {
+	for field, file := range p.files {
+		size, err := file.size()
+		if err != nil {
+			return err
+		}
+		if size > max {
+			return fmt.Errorf("%w: %s %q is %d bytes, the maximum is %d bytes", ErrFileTooLarge, field, file.name, size, max)
+		}
+	}
+	return nil
+}
+
 // Param set the parameter when calling bot's methods.
-type Param func(params url.Values)
+//
+// A Param used to be a func(url.Values), such a function is turned into a Param with ParamValues.
+// Params embeds the url.Values, so a custom Param usually only needs its argument type changed.
+type Param func(params *Params)
+
+// ParamValues returns a Param setting the url.Values of the params with fn,
+// to keep using a parameter written as a func(url.Values).
+//
+//	setSticker := func(v url.Values) { v.Set("sticker", fileID) }
+//	bot.MakeRequestParams("sendSticker", telegram.SetChatID(chatID), telegram.ParamValues(setSticker))
+func ParamValues(fn func(url.Values)) Param {
+	return func(params *Params) {
+		fn(params.Values)
+	}
+}
 
-// resolveParam returns url.Values generated from params.
-func resolveParam(params []Param) url.Values {
-	urlVal := url.Values{}
+// resolveParam returns Params generated from params.
+func resolveParam(params []Param) *Params {
+	p := newParams(nil)
 	for _, param := range params {
-		param(urlVal)
+		param(p)
 	}
-	return urlVal
+	return p
 }
 
 func setParamInt(field string, v int) Param {
-	return func(params url.Values) {
-		params.Set(field, strconv.Itoa(v))
+	return func(params *Params) {
+		params.setJSON(field, strconv.Itoa(v))
 	}
 }
 
 func setParamString(field string, v string) Param {
-	return func(params url.Values) {
+	return func(params *Params) {
 		params.Set(field, v)
 	}
 }
 
 func setParamBool(field string, v bool) Param {
-	return func(params url.Values) {
-		params.Set(field, strconv.FormatBool(v))
+	return func(params *Params) {
+		params.setJSON(field, strconv.FormatBool(v))
 	}
 }
 
+func setParamFile(field string, v InputFile) Param {
+	return func(params *Params) {
+		params.SetFile(field, v)
+	}
+}
+
+func setParamAttach(field string, v InputFile) Param {
+	return func(params *Params) {
+		params.setAttach(field, v)
+	}
+}
+
+func setParamJSON(field string, v interface{}) Param {
+	return func(params *Params) {
+		_ = params.SetJSON(field, v)
+	}
+}
+
+// SetChatID sets chat_id param, the chat a Call made with NewCall is sent to.
+func SetChatID(chatID int) Param {
+	return setParamInt("chat_id", chatID)
+}
+
 // SetOffset sets offset param.
 func SetOffset(offset int) Param {
 	return setParamInt("offset", offset)
@@ -51,20 +310,19 @@ func SetTimeout(timeout int) Param {
 	return setParamInt("timeout", timeout)
 }
 
-// SetAllowedUpdates set allowed_updates param.
-func SetAllowedUpdates(allowedUpdates ...string) Param {
-	return func(params url.Values) {
-		if len(allowedUpdates) == 0 {
-			allowedUpdates = make([]string, 0)
-		}
-
-		buf, err := json.Marshal(allowedUpdates)
-		if err != nil {
-			return
-		}
-
-		params.Set("allowed_updates", string(buf))
+// SetAllowedUpdates set allowed_updates param, the kinds of update the bot receives.
+// With no kind, the bot receives all kinds of update but chat_member.
+func SetAllowedUpdates(allowedUpdates ...UpdateType) Param {
+	if len(allowedUpdates) == 0 {
+		allowedUpdates = make([]UpdateType, 0)
 	}
+	return setParamJSON("allowed_updates", allowedUpdates)
+}
+
+// SetCertificate sets certificate param, the public key certificate of a self-signed webhook in PEM format.
+// The certificate has to be uploaded with InputFilePath or InputFileReader, see GenerateCertificate.
+func SetCertificate(cert InputFile) Param {
+	return setParamFile("certificate", cert)
 }
 
 // SetIPAddress sets ip_address param.
@@ -81,3 +339,84 @@ func SetMaxConnections(count int) Param {
 func SetDropPendingUpdates(b bool) Param {
 	return setParamBool("drop_pending_updates", b)
 }
+
+// SetSecretToken sets secret_token param.
+// Telegram sends the token in the X-Telegram-Bot-Api-Secret-Token header of every webhook request.
+func SetSecretToken(token string) Param {
+	return setParamString("secret_token", token)
+}
+
+// Parse modes of the text of a message or a caption, see SetParseMode.
+//
+// https://core.telegram.org/bots/api#formatting-options
+const (
+	ParseModeMarkdownV2 = "MarkdownV2"
+	ParseModeHTML       = "HTML"
+	ParseModeMarkdown   = "Markdown" // Legacy, use ParseModeMarkdownV2 instead.
+)
+
+// SetParseMode sets parse_mode param, one of ParseModeMarkdownV2, ParseModeHTML or ParseModeMarkdown.
+func SetParseMode(mode string) Param {
+	return setParamString("parse_mode", mode)
+}
+
+// SetCaption sets caption param, 0-1024 characters after entities parsing.
+func SetCaption(caption string) Param {
+	return setParamString("caption", caption)
+}
+
+// SetDuration sets duration param, in seconds.
+func SetDuration(seconds int) Param {
+	return setParamInt("duration", seconds)
+}
+
+// SetWidth sets width param.
+func SetWidth(width int) Param {
+	return setParamInt("width", width)
+}
+
+// SetHeight sets height param.
+func SetHeight(height int) Param {
+	return setParamInt("height", height)
+}
+
+// SetLength sets length param, the diameter of a video note.
+func SetLength(length int) Param {
+	return setParamInt("length", length)
+}
+
+// SetThumb sets thumb param, a JPEG thumbnail less than 200 kB and at most 320x320.
+// The thumbnail can't be reused, it has to be uploaded with InputFilePath or InputFileReader.
+func SetThumb(thumb InputFile) Param {
+	return setParamAttach("thumb", thumb)
+}
+
+// SetSupportsStreaming sets supports_streaming param, whether the video is suitable for streaming.
+func SetSupportsStreaming(b bool) Param {
+	return setParamBool("supports_streaming", b)
+}
+
+// SetPerformer sets performer param.
+func SetPerformer(performer string) Param {
+	return setParamString("performer", performer)
+}
+
+// SetTitle sets title param.
+func SetTitle(title string) Param {
+	return setParamString("title", title)
+}
+
+// SetDisableNotification sets disable_notification param, the message is sent silently.
+func SetDisableNotification(b bool) Param {
+	return setParamBool("disable_notification", b)
+}
+
+// SetReplyToMessageID sets reply_to_message_id param.
+func SetReplyToMessageID(messageID int) Param {
+	return setParamInt("reply_to_message_id", messageID)
+}
+
+// SetReplyMarkup sets reply_markup param, one of InlineKeyboardMarkup, ReplyKeyboardMarkup, ReplyKeyboardRemove or ForceReply.
+func SetReplyMarkup(markup ReplyMarkup) Param {
+	return setParamJSON("reply_markup", markup)
+}
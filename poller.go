@@ -0,0 +1,301 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Poller receives updates using long polling with GetUpdates.
+// It keeps track of the offset, so every update is handled at least once,
+// and backs off exponentially when GetUpdates or the handler fails.
+//
+// The offset is kept in memory unless a Store is set, then it survives a restart:
+//
+//	poller := telegram.NewPoller(bot)
+//	poller.Store = telegram.NewFileOffsetStore("offset.json")
+type Poller struct {
+	MinBackoff   time.Duration   // Backoff after the first failure of GetUpdates or the handler.
+	MaxBackoff   time.Duration   // Maximum backoff between failures of GetUpdates or the handler.
+	ErrorHandler func(err error) // Called with the errors of GetUpdates, the handler and the Store, if not nil.
+	Store        OffsetStore     // Persists the offset once the updates of every GetUpdates are handled, if not nil.
+	DedupWindow  int             // Number of last handled updates whose repeats are dropped.
+
+	bot     *Bot
+	params  []Param
+	offset  int
+	recent  []int
+	unsaved bool // The offset or the dedup window changed since they were saved to the Store.
+
+	mu  sync.Mutex
+	err error
+}
+
+// NewPoller returns a Poller receiving updates of the bot.
+// The params are passed to GetUpdates, the timeout is 50 seconds unless set otherwise.
+//
+//	Params: SetLimit, SetTimeout, SetAllowedUpdates.
+func NewPoller(bot *Bot, params ...Param) *Poller {
+	return &Poller{
+		MinBackoff:  time.Second,
+		MaxBackoff:  time.Minute,
+		DedupWindow: 100,
+		bot:         bot,
+		params:      append([]Param{SetTimeout(50)}, params...),
+	}
+}
+
+// Run receives updates and calls the handler for every update, one at a time unless the handler is a WorkerPool,
+// until the context is done.
+// Once the context is done, the offset of the last handled update is confirmed to Telegram,
+// so the handled updates are not received again, and Run returns the context error.
+//
+// The offset advances only once the handler succeeds. When the handler fails, the error is passed
+// to the ErrorHandler and the update is handled again after a backoff, so a handler that can't ever
+// succeed with an update should report the error itself and return nil.
+// An update whose id is one of the last DedupWindow handled updates is dropped without calling the handler,
+// the window is saved in the Store along with the offset.
+// The Store is saved once the updates of a GetUpdates are handled or the handler failed, and on shutdown,
+// so after a crash the handled updates of the last GetUpdates may be handled again.
+//
+// A WorkerPool handler gets all the received updates at once with HandleUpdateAsync, then the offset advances
+// as the updates are handled, up to the first failed one. The updates handled after the failed one
+// are added to the dedup window, so only the failed one is handled again.
+// The DedupWindow should then be at least the limit of GetUpdates, 100 by default.
+//
+// Run returns early with the error of GetUpdates when the token is invalid (ErrUnauthorized)
+// or the bot uses a webhook (ErrConflict, see Startup), and with the error of the Store when the offset can't be loaded.
+// Other errors are passed to the ErrorHandler.
+func (p *Poller) Run(ctx context.Context, handler UpdateHandler) error {
+	if err := p.load(); err != nil {
+		return err
+	}
+
+	backoff := p.MinBackoff
+
+	for {
+		updates, err := p.bot.getUpdates(ctx, keepRaw(handler), p.requestParams())
+		if ctx.Err() != nil {
+			return p.shutdown(ctx.Err())
+		}
+		if err != nil {
+			if errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrConflict) {
+				return err
+			}
+		} else {
+			err = p.handleUpdates(ctx, handler, updates)
+			p.save()
+			if ctx.Err() != nil {
+				return p.shutdown(ctx.Err())
+			}
+		}
+
+		if err != nil {
+			p.handleError(err)
+
+			if err := sleepContext(ctx, p.wait(err, backoff)); err != nil {
+				return p.shutdown(err)
+			}
+			if backoff *= 2; backoff > p.MaxBackoff {
+				backoff = p.MaxBackoff
+			}
+			continue
+		}
+		backoff = p.MinBackoff
+	}
+}
+
+// handleUpdates calls the handler for every update not handled yet, committing the offset after each one.
+// It stops at the first failure of the handler, leaving the offset at the failed update.
+func (p *Poller) handleUpdates(ctx context.Context, handler UpdateHandler, updates []Update) error {
+	if handler, ok := handler.(asyncUpdateHandler); ok {
+		return p.handleUpdatesAsync(ctx, handler, updates)
+	}
+
+	for _, update := range updates {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if !p.seen(update.UpdateID) {
+			if err := handler.HandleUpdate(ctx, update); err != nil {
+				return err
+			}
+		}
+		p.commit(update.UpdateID)
+	}
+	return nil
+}
+
+// asyncUpdateHandler is an UpdateHandler reporting the result of an update once it's handled, such as WorkerPool.
+type asyncUpdateHandler interface {
+	HandleUpdateAsync(ctx context.Context, update Update) (<-chan error, error)
+}
+
+// handleUpdatesAsync queues every update not handled yet, then commits the offset of the updates
+// in order as they're handled. The offset stops at the first failed update,
+// the updates handled after it are only added to the dedup window.
+func (p *Poller) handleUpdatesAsync(ctx context.Context, handler asyncUpdateHandler, updates []Update) error {
+	var (
+		results  = make([]<-chan error, len(updates))
+		queued   = 0
+		queueErr error
+	)
+	for _, update := range updates {
+		if !p.seen(update.UpdateID) {
+			if results[queued], queueErr = handler.HandleUpdateAsync(ctx, update); queueErr != nil {
+				break
+			}
+		}
+		queued++
+	}
+
+	var failed error
+	for i, update := range updates[:queued] {
+		if results[i] != nil {
+			select {
+			case err := <-results[i]:
+				if err != nil {
+					if failed == nil {
+						failed = err
+					}
+					continue
+				}
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		if failed == nil {
+			p.commit(update.UpdateID)
+		} else {
+			p.remember(update.UpdateID)
+		}
+	}
+
+	if failed != nil {
+		return failed
+	}
+	return queueErr
+}
+
+// load restores the offset and the dedup window from the Store.
+func (p *Poller) load() error {
+	if p.Store == nil {
+		return nil
+	}
+
+	state, err := p.Store.Load()
+	if err != nil {
+		return err
+	}
+	p.offset, p.recent = state.Offset, state.Recent
+	return nil
+}
+
+// seen reports whether the update is one of the last handled updates.
+func (p *Poller) seen(updateID int) bool {
+	for _, id := range p.recent {
+		if id == updateID {
+			return true
+		}
+	}
+	return false
+}
+
+// commit advances the offset past the handled update, see save.
+func (p *Poller) commit(updateID int) {
+	p.offset = updateID + 1
+	p.remember(updateID)
+}
+
+// remember adds the handled update to the dedup window, see save.
+func (p *Poller) remember(updateID int) {
+	if !p.seen(updateID) && p.DedupWindow > 0 {
+		if len(p.recent) >= p.DedupWindow {
+			p.recent = append(p.recent[:0], p.recent[len(p.recent)-p.DedupWindow+1:]...)
+		}
+		p.recent = append(p.recent, updateID)
+	}
+	p.unsaved = true
+}
+
+// save saves the offset and the dedup window to the Store, if they changed since the last save.
+// It's called once the updates received by a GetUpdates are handled, or the handler failed, and on shutdown.
+func (p *Poller) save() {
+	if p.Store == nil || !p.unsaved {
+		return
+	}
+	p.unsaved = false
+	if err := p.Store.Save(OffsetState{Offset: p.offset, Recent: append([]int(nil), p.recent...)}); err != nil {
+		p.handleError(err)
+	}
+}
+
+// Updates runs the Poller in a new goroutine and returns a channel of the received updates.
+// The channel is closed once the Poller stops, then Err returns the reason.
+// An update is confirmed once it's received from the channel.
+func (p *Poller) Updates(ctx context.Context) <-chan Update {
+	ch := make(chan Update)
+
+	go func() {
+		defer close(ch)
+
+		err := p.Run(ctx, ChannelHandler(ch))
+
+		p.mu.Lock()
+		p.err = err
+		p.mu.Unlock()
+	}()
+
+	return ch
+}
+
+// Err returns the error Run returned, once the channel returned by Updates is closed.
+func (p *Poller) Err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.err
+}
+
+// requestParams returns the params of GetUpdates with the current offset.
+func (p *Poller) requestParams() []Param {
+	if p.offset == 0 {
+		return p.params
+	}
+	return append(p.params[:len(p.params):len(p.params)], SetOffset(p.offset))
+}
+
+// wait returns how long to wait after GetUpdates failed with err.
+func (p *Poller) wait(err error, backoff time.Duration) time.Duration {
+	if wait := retryAfter(err); wait > backoff {
+		return wait
+	}
+	return backoff
+}
+
+// shutdown saves and confirms the offset of the handled updates and returns err.
+func (p *Poller) shutdown(err error) error {
+	p.save()
+	if p.offset == 0 {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// updates below the offset are confirmed by calling GetUpdates with the offset.
+	if _, confirmErr := p.bot.GetUpdatesContext(ctx, SetOffset(p.offset), SetLimit(1), SetTimeout(0)); confirmErr != nil {
+		p.handleError(confirmErr)
+	}
+
+	return err
+}
+
+func (p *Poller) handleError(err error) {
+	if p.ErrorHandler != nil {
+		p.ErrorHandler(err)
+	}
+}
@@ -0,0 +1,356 @@
+package telegram_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/62bot/telegram"
+	"github.com/billyzaelani/is"
+)
+
+// fakeUpdates is a fake getUpdates endpoint serving updates from 1 to last,
+// limit updates at a time, failing with a network error every failEvery requests.
+type fakeUpdates struct {
+	mu        sync.Mutex
+	last      int
+	limit     int
+	failEvery int
+	requests  []url.Values
+}
+
+func (f *fakeUpdates) roundTrip(r *http.Request) (*http.Response, error) {
+	if r.URL.Path == "/bot"+validTestToken+"/getMe" {
+		return newHTTPResponse(authorizedCase.StatusCode, authorizedCase.Body), nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	params := requestParams(r)
+	f.requests = append(f.requests, params)
+	if f.failEvery > 0 && len(f.requests)%f.failEvery == 0 {
+		return nil, errors.New("connection reset by peer")
+	}
+
+	offset := 1
+	if params.Get("offset") != "" {
+		offset, _ = strconv.Atoi(params.Get("offset"))
+	}
+
+	updates := []telegram.Update{}
+	for id := offset; id <= f.last && len(updates) < f.limit; id++ {
+		updates = append(updates, telegram.Update{UpdateID: id, Message: &telegram.Message{MessageID: id, Chat: &telegram.Chat{ID: 1}}})
+	}
+
+	result, _ := json.Marshal(updates)
+	body, _ := json.Marshal(telegram.Response{OK: true, Result: result})
+	return newHTTPResponse(http.StatusOK, body), nil
+}
+
+func (f *fakeUpdates) offsets() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var offsets []string
+	for _, params := range f.requests {
+		offsets = append(offsets, params.Get("offset"))
+	}
+	return offsets
+}
+
+func newFakeUpdatesBot(is *is.Is, f *fakeUpdates) *telegram.Bot {
+	client := &http.Client{Transport: roundTripperFunc(f.roundTrip)}
+	bot, err := telegram.NewBot(validTestToken, telegram.SetClient(client))
+	is.NoError(err)
+	return bot
+}
+
+// TestPollerRun tests that the poller handles every update once and confirms the offset on shutdown.
+func TestPollerRun(t *testing.T) {
+	is := is.New(t)
+
+	f := &fakeUpdates{last: 5, limit: 2, failEvery: 2}
+	bot := newFakeUpdatesBot(is, f)
+
+	poller := telegram.NewPoller(bot, telegram.SetLimit(2))
+	poller.MinBackoff = time.Millisecond
+	poller.MaxBackoff = time.Millisecond
+
+	var errs int
+	poller.ErrorHandler = func(err error) {
+		var networkError *telegram.NetworkError
+		is.ErrorAs(err, &networkError) // only network errors happen
+		errs++
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var got []int
+	err := poller.Run(ctx, telegram.UpdateHandlerFunc(func(ctx context.Context, update telegram.Update) error {
+		got = append(got, update.UpdateID)
+		if update.UpdateID == 5 {
+			cancel()
+		}
+		return nil
+	}))
+	is.Error(err, context.Canceled)
+
+	is.Equal(got, []int{1, 2, 3, 4, 5})
+	is.True(errs > 0)
+
+	offsets := f.offsets()
+	is.Equal(offsets[0], "")               // first request has no offset
+	is.Equal(offsets[len(offsets)-1], "6") // shutdown confirms the last update
+	for _, params := range f.requests[:len(f.requests)-1] {
+		is.Equal(params.Get("limit"), "2")    // params are passed to getUpdates
+		is.Equal(params.Get("timeout"), "50") // long polling by default
+	}
+}
+
+// TestPollerUpdates tests receiving updates from a channel.
+func TestPollerUpdates(t *testing.T) {
+	is := is.New(t)
+
+	f := &fakeUpdates{last: 3, limit: 100}
+	bot := newFakeUpdatesBot(is, f)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	poller := telegram.NewPoller(bot)
+	updates := poller.Updates(ctx)
+
+	for want := 1; want <= 3; want++ {
+		update := <-updates
+		is.Equal(update.UpdateID, want)
+	}
+	cancel()
+
+	for range updates {
+	}
+	is.Error(poller.Err(), context.Canceled)
+}
+
+// TestPollerConflict tests that the poller stops when the bot uses a webhook.
+func TestPollerConflict(t *testing.T) {
+	is := is.New(t)
+
+	testCases := testFixture.get("getUpdates")
+	tc := testCases.get("conflict_with_webhook")
+
+	client := newTestClient(func(methodName string, params url.Values) *http.Response {
+		return newHTTPResponse(tc.StatusCode, tc.Body)
+	})
+	bot, err := telegram.NewBot(validTestToken, telegram.SetClient(client))
+	is.NoError(err)
+
+	err = telegram.NewPoller(bot).Run(context.Background(), telegram.UpdateHandlerFunc(func(ctx context.Context, update telegram.Update) error {
+		return nil
+	}))
+	is.Error(err, telegram.ErrConflict)
+}
+
+// TestPollerRedeliver tests that an update is handled again when the handler fails.
+func TestPollerRedeliver(t *testing.T) {
+	is := is.New(t)
+
+	f := &fakeUpdates{last: 4, limit: 100}
+	bot := newFakeUpdatesBot(is, f)
+
+	poller := telegram.NewPoller(bot)
+	poller.MinBackoff = time.Millisecond
+
+	errHandler := errors.New("handler failed")
+	var errs []error
+	poller.ErrorHandler = func(err error) { errs = append(errs, err) }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var got []int
+	failed := false
+	err := poller.Run(ctx, telegram.UpdateHandlerFunc(func(ctx context.Context, update telegram.Update) error {
+		got = append(got, update.UpdateID)
+		if update.UpdateID == 2 && !failed {
+			failed = true
+			return errHandler
+		}
+		if update.UpdateID == 4 {
+			cancel()
+		}
+		return nil
+	}))
+	is.Error(err, context.Canceled)
+
+	is.Equal(got, []int{1, 2, 2, 3, 4}) // failed update is handled again
+	is.Equal(errs, []error{errHandler})
+
+	offsets := f.offsets()
+	is.Equal(offsets[1], "2") // refetched from the failed update
+}
+
+// TestPollerWorkerPool tests that the poller commits the offset of the updates handled by a worker pool
+// only once they're handled, and handles a failed update again without the updates handled after it.
+func TestPollerWorkerPool(t *testing.T) {
+	is := is.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	f := &fakeUpdates{last: 4, limit: 100}
+	client := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		resp, err := f.roundTrip(r)
+		if offsets := f.offsets(); len(offsets) > 0 && offsets[len(offsets)-1] == "5" {
+			cancel() // every update is handled
+		}
+		return resp, err
+	})}
+	bot, err := telegram.NewBot(validTestToken, telegram.SetClient(client))
+	is.NoError(err)
+
+	errHandler := errors.New("handler failed")
+	var (
+		mu     sync.Mutex
+		got    []int
+		failed = false
+	)
+	pool := telegram.NewWorkerPool(telegram.UpdateHandlerFunc(func(ctx context.Context, update telegram.Update) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		got = append(got, update.UpdateID)
+		if update.UpdateID == 2 && !failed {
+			failed = true
+			return errHandler
+		}
+		return nil
+	}), 0, 0)
+	defer pool.Close()
+
+	poller := telegram.NewPoller(bot)
+	poller.MinBackoff = time.Millisecond
+
+	var errs []error
+	poller.ErrorHandler = func(err error) { errs = append(errs, err) }
+
+	err = poller.Run(ctx, pool)
+	is.Error(err, context.Canceled)
+
+	mu.Lock()
+	is.Equal(got, []int{1, 2, 3, 4, 2}) // only the failed update is handled again
+	mu.Unlock()
+	is.Equal(errs, []error{errHandler})
+
+	offsets := f.offsets()
+	is.Equal(offsets[1], "2")              // refetched from the failed update
+	is.Equal(offsets[len(offsets)-1], "5") // shutdown confirms the last update
+}
+
+// TestPollerStore tests that a restarted poller continues from the stored offset
+// and drops the updates it already handled.
+func TestPollerStore(t *testing.T) {
+	is := is.New(t)
+
+	store := telegram.NewFileOffsetStore(filepath.Join(t.TempDir(), "offset.json"))
+
+	run := func(last, cancelAt int) []int {
+		f := &fakeUpdates{last: last, limit: 100}
+		bot := newFakeUpdatesBot(is, f)
+
+		poller := telegram.NewPoller(bot)
+		poller.Store = store
+
+		ctx, cancel := context.WithCancel(context.Background())
+		var got []int
+		err := poller.Run(ctx, telegram.UpdateHandlerFunc(func(ctx context.Context, update telegram.Update) error {
+			got = append(got, update.UpdateID)
+			if update.UpdateID == cancelAt {
+				cancel()
+			}
+			return nil
+		}))
+		is.Error(err, context.Canceled)
+		return got
+	}
+
+	is.Equal(run(3, 3), []int{1, 2, 3})
+
+	state, err := store.Load()
+	is.NoError(err)
+	is.Equal(state, telegram.OffsetState{Offset: 4, Recent: []int{1, 2, 3}})
+
+	is.Equal(run(5, 5), []int{4, 5}) // restarted from the stored offset
+
+	// Telegram sends handled updates again, such as when the offset wasn't confirmed.
+	is.NoError(store.Save(telegram.OffsetState{Offset: 4, Recent: []int{4, 5}}))
+	is.Equal(run(6, 6), []int{6}) // repeated updates are dropped
+}
+
+// countingStore is an OffsetStore counting the saves.
+type countingStore struct {
+	telegram.MemoryOffsetStore
+	saves int
+}
+
+func (s *countingStore) Save(state telegram.OffsetState) error {
+	s.saves++
+	return s.MemoryOffsetStore.Save(state)
+}
+
+// TestPollerStoreBatch tests that the offset is saved once per batch of updates rather than after every update.
+func TestPollerStoreBatch(t *testing.T) {
+	is := is.New(t)
+
+	f := &fakeUpdates{last: 5, limit: 100}
+	bot := newFakeUpdatesBot(is, f)
+
+	store := &countingStore{}
+	poller := telegram.NewPoller(bot)
+	poller.Store = store
+
+	ctx, cancel := context.WithCancel(context.Background())
+	err := poller.Run(ctx, telegram.UpdateHandlerFunc(func(ctx context.Context, update telegram.Update) error {
+		if update.UpdateID == 5 {
+			cancel()
+		}
+		return nil
+	}))
+	is.Error(err, context.Canceled)
+
+	is.Equal(store.saves, 1)
+	state, err := store.Load()
+	is.NoError(err)
+	is.Equal(state.Offset, 6)
+}
+
+// TestFileOffsetStore tests saving and loading the state of a poller in a file.
+func TestFileOffsetStore(t *testing.T) {
+	is := is.New(t)
+
+	path := filepath.Join(t.TempDir(), "offset.json")
+	store := telegram.NewFileOffsetStore(path)
+
+	state, err := store.Load()
+	is.NoError(err)
+	is.Equal(state, telegram.OffsetState{}) // missing file
+
+	want := telegram.OffsetState{Offset: 10, Recent: []int{8, 9}}
+	is.NoError(store.Save(want))
+	state, err = telegram.NewFileOffsetStore(path).Load()
+	is.NoError(err)
+	is.Equal(state, want)
+
+	files, err := ioutil.ReadDir(filepath.Dir(path))
+	is.NoError(err)
+	is.Equal(len(files), 1) // no temporary file left
+
+	is.NoError(ioutil.WriteFile(path, []byte("{"), 0600))
+	_, err = store.Load()
+	is.Error(err) // corrupted file
+}
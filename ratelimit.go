@@ -0,0 +1,192 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimit allows Count requests in every Per duration. A zero RateLimit is unlimited.
+type RateLimit struct {
+	Count int
+	Per   time.Duration
+}
+
+// RateLimits are the limits of requests sent to chats.
+//
+// https://core.telegram.org/bots/faq#my-bot-is-hitting-limits-how-do-i-avoid-this
+type RateLimits struct {
+	Global      RateLimit // Requests to all chats.
+	PrivateChat RateLimit // Requests to a single private chat.
+	GroupChat   RateLimit // Requests to a single group, supergroup or channel.
+
+	// Methods are the names of the limited methods, nil limits the methods sending a message.
+	// See SendMethods. The requests of the bulk methods, such as DeleteMessages, are limited anyway.
+	Methods []string
+}
+
+// DefaultRateLimits returns the limits documented by Telegram:
+// 30 messages per second overall, 1 message per second to a private chat
+// and 20 messages per minute to a group.
+func DefaultRateLimits() RateLimits {
+	return RateLimits{
+		Global:      RateLimit{Count: 30, Per: time.Second},
+		PrivateChat: RateLimit{Count: 1, Per: time.Second},
+		GroupChat:   RateLimit{Count: 20, Per: time.Minute},
+	}
+}
+
+// SendMethods returns the names of the methods sending a message to a chat,
+// they are the methods limited by default.
+func SendMethods() []string {
+	return []string{
+		"sendMessage", "forwardMessage", "copyMessage",
+		"sendPhoto", "sendAudio", "sendDocument", "sendVideo", "sendAnimation", "sendVoice", "sendVideoNote", "sendMediaGroup",
+		"sendLocation", "sendVenue", "sendContact", "sendPoll", "sendDice", "sendSticker", "sendInvoice", "sendGame",
+	}
+}
+
+// rateLimiter delays requests having a chat_id param to keep them within the limits.
+// A request first waits for a slot of its chat, then for a global slot.
+// Requests get their slots in the order they arrive, so a waiting request is never overtaken
+// and a backlog of one chat doesn't delay the other chats.
+type rateLimiter struct {
+	limits  RateLimits
+	methods map[string]bool
+
+	mu        sync.Mutex
+	global    bucket
+	chats     map[string]*bucket
+	nextPrune int
+}
+
+func newRateLimiter(limits RateLimits) *rateLimiter {
+	methods := limits.Methods
+	if methods == nil {
+		methods = SendMethods()
+	}
+
+	l := &rateLimiter{
+		limits:    limits,
+		methods:   make(map[string]bool, len(methods)),
+		chats:     make(map[string]*bucket),
+		nextPrune: minPruneSize,
+	}
+	for _, methodName := range methods {
+		l.methods[methodName] = true
+	}
+	return l
+}
+
+// minPruneSize is the number of chat buckets kept before idle buckets are removed.
+const minPruneSize = 1024
+
+// wait blocks until the request with params is allowed to be sent or the context is done.
+func (l *rateLimiter) wait(ctx context.Context, methodName string, params *Params) error {
+	chatID := params.Get("chat_id")
+	if chatID == "" || !l.limited(methodName, params) && !isBulk(ctx) {
+		return nil
+	}
+
+	if err := sleepUntil(ctx, l.reserveChat(time.Now(), chatID)); err != nil {
+		return err
+	}
+	return sleepUntil(ctx, l.reserveGlobal(time.Now()))
+}
+
+// limited reports whether the request is delayed to keep within the limits.
+func (l *rateLimiter) limited(methodName string, params *Params) bool {
+	return l.methods[methodName] && params.Get("chat_id") != ""
+}
+
+// sleepUntil blocks until the time at or until the context is done.
+func sleepUntil(ctx context.Context, at time.Time) error {
+	if d := time.Until(at); d > 0 {
+		return sleepContext(ctx, d)
+	}
+	return nil
+}
+
+// reserveGlobal reserves the next global slot and returns the time the slot starts.
+// The global slot is reserved once the chat slot is reached, so the requests waiting for their chat don't hold any.
+func (l *rateLimiter) reserveGlobal(now time.Time) time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.global.reserve(now, l.limits.Global)
+}
+
+// reserveChat reserves the next slot of the chat and returns the time the slot starts.
+func (l *rateLimiter) reserveChat(now time.Time, chatID string) time.Time {
+	limit := l.limits.PrivateChat
+	if isGroupChatID(chatID) {
+		limit = l.limits.GroupChat
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.chats[chatID]
+	if !ok {
+		l.prune(now)
+		b = &bucket{}
+		l.chats[chatID] = b
+	}
+
+	return b.reserve(now, limit)
+}
+
+// prune removes the idle chat buckets when there are too many of them.
+func (l *rateLimiter) prune(now time.Time) {
+	if len(l.chats) < l.nextPrune {
+		return
+	}
+
+	for chatID, b := range l.chats {
+		if b.idle(now) {
+			delete(l.chats, chatID)
+		}
+	}
+
+	l.nextPrune = 2 * len(l.chats)
+	if l.nextPrune < minPruneSize {
+		l.nextPrune = minPruneSize
+	}
+}
+
+// isGroupChatID reports whether the chat_id param refers to a group, a supergroup or a channel.
+// Those have negative identifiers while channels may also be referred by @username.
+func isGroupChatID(chatID string) bool {
+	return strings.HasPrefix(chatID, "-") || strings.HasPrefix(chatID, "@")
+}
+
+// bucket implements the generic cell rate algorithm (GCRA),
+// it allows a burst of limit.Count requests followed by one request every limit.Per/limit.Count.
+type bucket struct {
+	tat time.Time // Theoretical arrival time of the next request.
+}
+
+// reserve reserves a slot not earlier than at and returns the time the slot starts.
+func (b *bucket) reserve(at time.Time, limit RateLimit) time.Time {
+	if limit.Count <= 0 || limit.Per <= 0 {
+		return at
+	}
+
+	interval := limit.Per / time.Duration(limit.Count)
+	if earliest := b.tat.Add(interval - limit.Per); earliest.After(at) {
+		at = earliest
+	}
+
+	if b.tat.Before(at) {
+		b.tat = at
+	}
+	b.tat = b.tat.Add(interval)
+
+	return at
+}
+
+// idle reports whether the bucket is back to its initial state.
+func (b *bucket) idle(now time.Time) bool {
+	return !b.tat.After(now)
+}
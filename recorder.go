@@ -0,0 +1,156 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// RecordedUpdate is an update recorded by a Recorder, one JSON object per line.
+type RecordedUpdate struct {
+	Time   time.Time       `json:"time"`   // When the update was received.
+	Update json.RawMessage `json:"update"` // The update as received, see Update.Raw.
+}
+
+// Recorder is an UpdateHandler recording every update before passing it to its handler,
+// so the updates that triggered a bug can be replayed later with a Replayer.
+// Given to a Poller or a WebhookHandler, directly or through a WorkerPool, it records the updates as received,
+// see Update.Raw. A Recorder may wrap a WorkerPool as well, the Poller still waits for the updates to be handled.
+//
+//	f, err := os.OpenFile("updates.jsonl", os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+//	...
+//	err = telegram.NewPoller(bot).Run(ctx, telegram.NewRecorder(f, dispatcher))
+type Recorder struct {
+	ErrorHandler func(err error) // Called with the errors of recording an update, if not nil.
+
+	w       io.Writer
+	handler UpdateHandler
+
+	mu sync.Mutex
+}
+
+// NewRecorder returns a Recorder appending the updates to w as RecordedUpdate JSON lines, then calling the handler.
+func NewRecorder(w io.Writer, handler UpdateHandler) *Recorder {
+	return &Recorder{w: w, handler: handler}
+}
+
+// HandleUpdate records the update and calls the handler, returning its error.
+// A failure to record the update is passed to the ErrorHandler, the update is handled anyway.
+func (r *Recorder) HandleUpdate(ctx context.Context, update Update) error {
+	if err := r.record(update); err != nil && r.ErrorHandler != nil {
+		r.ErrorHandler(err)
+	}
+	return r.handler.HandleUpdate(ctx, update)
+}
+
+// HandleUpdateAsync records the update and passes it to the handler like HandleUpdate, and returns a channel
+// receiving the error of the handler once the update is handled. The update is handled asynchronously only
+// when the handler is a WorkerPool, so a Poller commits the offset once the update is handled, see Poller.Run.
+func (r *Recorder) HandleUpdateAsync(ctx context.Context, update Update) (<-chan error, error) {
+	if err := r.record(update); err != nil && r.ErrorHandler != nil {
+		r.ErrorHandler(err)
+	}
+
+	if handler, ok := r.handler.(asyncUpdateHandler); ok {
+		return handler.HandleUpdateAsync(ctx, update)
+	}
+
+	done := make(chan error, 1)
+	done <- r.handler.HandleUpdate(ctx, update)
+	return done, nil
+}
+
+// rawUpdateHandler is an UpdateHandler telling whether it needs the updates as received, see Update.Raw.
+type rawUpdateHandler interface {
+	keepsRawUpdates() bool
+}
+
+// keepRaw reports whether the handler needs the updates as received, see Update.Raw.
+func keepRaw(handler UpdateHandler) bool {
+	h, ok := handler.(rawUpdateHandler)
+	return ok && h.keepsRawUpdates()
+}
+
+// keepsRawUpdates reports that the Recorder needs the updates as received.
+func (r *Recorder) keepsRawUpdates() bool {
+	return true
+}
+
+// record writes the update as a single line.
+func (r *Recorder) record(update Update) error {
+	raw, err := update.Raw()
+	if err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(RecordedUpdate{Time: time.Now(), Update: raw})
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, err = r.w.Write(append(line, '\n'))
+	return err
+}
+
+// Replayer feeds the updates recorded by a Recorder to a handler, as fast as possible
+// or with the timing they were received with.
+// The handler is usually given a bot made with SetDryRun, so replaying never sends a request:
+//
+//	bot, err := telegram.NewBot(token, telegram.SetDryRun(os.Stdout))
+//	...
+//	f, err := os.Open("updates.jsonl")
+//	...
+//	err = telegram.NewReplayer(f).Run(ctx, newDispatcher(bot))
+type Replayer struct {
+	RealTime     bool            // Waits between updates as long as between their recording.
+	ErrorHandler func(err error) // Called with the errors of the handler, if not nil.
+
+	r io.Reader
+}
+
+// NewReplayer returns a Replayer reading RecordedUpdate JSON lines from r.
+func NewReplayer(r io.Reader) *Replayer {
+	return &Replayer{r: r}
+}
+
+// Run calls the handler for every recorded update, one at a time, until the end of the records.
+// It returns nil once all the updates are handled, the context error when the context is done,
+// or an error when a record can't be read. The errors of the handler are passed to the ErrorHandler.
+func (rp *Replayer) Run(ctx context.Context, handler UpdateHandler) error {
+	dec := json.NewDecoder(rp.r)
+
+	var last time.Time
+	for n := 1; ; n++ {
+		var record RecordedUpdate
+		if err := dec.Decode(&record); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("telegram: replay: record %d: %w", n, err)
+		}
+
+		var update Update
+		if err := json.Unmarshal(record.Update, &update); err != nil {
+			return fmt.Errorf("telegram: replay: record %d: %w", n, err)
+		}
+
+		if rp.RealTime && !last.IsZero() {
+			if err := sleepContext(ctx, record.Time.Sub(last)); err != nil {
+				return err
+			}
+		}
+		last = record.Time
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := handler.HandleUpdate(ctx, update); err != nil && rp.ErrorHandler != nil {
+			rp.ErrorHandler(err)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package telegram_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/62bot/telegram"
+	"github.com/billyzaelani/is"
+)
+
+// TestRecordReplay tests recording updates and replaying them to a dry run bot.
+func TestRecordReplay(t *testing.T) {
+	is := is.New(t)
+
+	updates := []string{
+		`{"update_id":1,"message":{"message_id":1,"chat":{"id":2,"type":"private"},"text":"hi"},"unknown_field":true}`,
+		`{"update_id":2,"message":{"message_id":2,"chat":{"id":2,"type":"private"},"text":"bye"}}`,
+	}
+
+	var records bytes.Buffer
+	handled := make(chan telegram.Update)
+	recorder := telegram.NewRecorder(&records, telegram.UpdateHandlerFunc(func(ctx context.Context, update telegram.Update) error {
+		handled <- update
+		return nil
+	}))
+	webhook := telegram.NewWebhookHandler("", recorder)
+	for _, s := range updates {
+		w := httptest.NewRecorder()
+		webhook.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(s)))
+		is.Equal(w.Code, http.StatusOK)
+		<-handled
+	}
+
+	lines := strings.Split(strings.TrimSpace(records.String()), "\n")
+	is.Equal(len(lines), len(updates))
+	for i, line := range lines {
+		var record telegram.RecordedUpdate
+		is.NoError(json.Unmarshal([]byte(line), &record))
+		is.Equal(string(record.Update), updates[i]) // update recorded as received
+		is.True(!record.Time.IsZero())
+	}
+
+	var requests bytes.Buffer
+	bot, err := telegram.NewBot(validTestToken, telegram.SetDryRun(&requests), telegram.SetLazy())
+	is.NoError(err)
+
+	err = telegram.NewReplayer(&records).Run(context.Background(), telegram.UpdateHandlerFunc(func(ctx context.Context, update telegram.Update) error {
+		_, err := bot.SendMessageContext(ctx, update.Chat().ID, "echo: "+update.Message.Text)
+		return err
+	}))
+	is.NoError(err)
+
+	is.Equal(requests.String(),
+		`{"chat_id":2,"method":"sendMessage","text":"echo: hi"}`+"\n"+
+			`{"chat_id":2,"method":"sendMessage","text":"echo: bye"}`+"\n")
+}
+
+// TestUpdateRaw tests that an update not received by a Recorder is encoded again, and stays comparable.
+func TestUpdateRaw(t *testing.T) {
+	is := is.New(t)
+
+	var update telegram.Update
+	is.NoError(json.Unmarshal([]byte(`{"update_id":1,"unknown_field":true}`), &update))
+
+	raw, err := update.Raw()
+	is.NoError(err)
+	is.Equal(string(raw), `{"update_id":1}`) // not kept as received
+
+	is.True(update == telegram.Update{UpdateID: 1})
+}
+
+// TestReplayRealTime tests replaying updates with the timing they were recorded with.
+func TestReplayRealTime(t *testing.T) {
+	is := is.New(t)
+
+	start := time.Now()
+	var records bytes.Buffer
+	for i, d := range []time.Duration{0, 50 * time.Millisecond} {
+		line, err := json.Marshal(telegram.RecordedUpdate{Time: start.Add(d), Update: json.RawMessage(`{"update_id":` + string(rune('1'+i)) + `}`)})
+		is.NoError(err)
+		records.Write(append(line, '\n'))
+	}
+	records.WriteString("not json\n")
+
+	var got []int
+	replayer := telegram.NewReplayer(&records)
+	replayer.RealTime = true
+	err := replayer.Run(context.Background(), telegram.UpdateHandlerFunc(func(ctx context.Context, update telegram.Update) error {
+		got = append(got, update.UpdateID)
+		return nil
+	}))
+	is.Error(err) // malformed record
+
+	is.Equal(got, []int{1, 2})
+	is.True(time.Since(start) >= 50*time.Millisecond) // waited between updates
+}
+
+// TestRecorderWorkerPool tests that a Recorder composed with a WorkerPool records the updates as received,
+// and that the Poller handles a failed update again whatever the order of the composition.
+func TestRecorderWorkerPool(t *testing.T) {
+	const chatMember = `{"update_id":1,"chat_member":{"chat":{"id":2,"type":"group"}}}`
+	errHandler := errors.New("handler failed")
+
+	compositions := map[string]func(w io.Writer, handler telegram.UpdateHandler) (telegram.UpdateHandler, func()){
+		"pool_of_recorder": func(w io.Writer, handler telegram.UpdateHandler) (telegram.UpdateHandler, func()) {
+			pool := telegram.NewWorkerPool(telegram.NewRecorder(w, handler), 0, 0)
+			return pool, pool.Close
+		},
+		"recorder_of_pool": func(w io.Writer, handler telegram.UpdateHandler) (telegram.UpdateHandler, func()) {
+			pool := telegram.NewWorkerPool(handler, 0, 0)
+			return telegram.NewRecorder(w, pool), pool.Close
+		},
+	}
+
+	for name, compose := range compositions {
+		t.Run(name, func(t *testing.T) {
+			is := is.New(t)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var offsets []string
+			client := newTestClient(func(methodName string, params url.Values) *http.Response {
+				offset := params.Get("offset")
+				offsets = append(offsets, offset)
+				result := `[]`
+				switch offset {
+				case "":
+					result = `[` + chatMember + `,{"update_id":2,"message":{"message_id":1,"date":0,"chat":{"id":2,"type":"group"}}}]`
+				case "2":
+					result = `[{"update_id":2,"message":{"message_id":1,"date":0,"chat":{"id":2,"type":"group"}}}]`
+				default:
+					cancel() // every update is handled
+				}
+				return newHTTPResponse(http.StatusOK, []byte(`{"ok":true,"result":`+result+`}`))
+			})
+			bot, err := telegram.NewBot(validTestToken, telegram.SetClient(client))
+			is.NoError(err)
+
+			var (
+				mu      sync.Mutex
+				handled []int
+				failed  bool
+				records bytes.Buffer
+			)
+			handler, closePool := compose(&records, telegram.UpdateHandlerFunc(func(ctx context.Context, update telegram.Update) error {
+				mu.Lock()
+				defer mu.Unlock()
+
+				handled = append(handled, update.UpdateID)
+				if update.UpdateID == 2 && !failed {
+					failed = true
+					return errHandler
+				}
+				return nil
+			}))
+
+			poller := telegram.NewPoller(bot)
+			poller.MinBackoff = time.Millisecond
+			is.Error(poller.Run(ctx, handler), context.Canceled)
+			closePool()
+
+			sort.Ints(handled)                // the updates of different shards are handled concurrently
+			is.Equal(handled, []int{1, 2, 2}) // the failed update is handled again
+			is.Equal(offsets[1], "2")         // the offset isn't committed before the update is handled
+
+			var recorded []string
+			for dec := json.NewDecoder(&records); dec.More(); {
+				var record telegram.RecordedUpdate
+				is.NoError(dec.Decode(&record))
+				recorded = append(recorded, string(record.Update))
+			}
+			is.True(len(recorded) == 3)
+			is.True(recorded[0] == chatMember || recorded[1] == chatMember) // recorded as received, with its unknown kind
+		})
+	}
+}
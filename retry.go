@@ -0,0 +1,125 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RetryPolicy tells the bot how to retry failed requests.
+//
+// A request failed with 429 Too Many Requests is retried after the RetryAfter seconds given by Telegram.
+// A request failed with a server error (5xx) or a network error is retried with an exponential backoff,
+// starting from MinBackoff and doubled for every retry up to MaxBackoff.
+// Any other failure is returned right away.
+//
+// Non-idempotent methods like sendMessage are never retried unless RetryNonIdempotent is set,
+// since a request that failed on a network error might have been already processed by Telegram.
+// A request uploading a file from an io.Reader is never retried since the reader can't be read again.
+type RetryPolicy struct {
+	MaxAttempts        int           // Maximum number of attempts, including the first one.
+	MinBackoff         time.Duration // Backoff before the first retry.
+	MaxBackoff         time.Duration // Maximum backoff between retries.
+	RetryNonIdempotent bool          // Retry non-idempotent methods as well.
+}
+
+// DefaultRetryPolicy returns a RetryPolicy with sensible defaults.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		MinBackoff:  500 * time.Millisecond,
+		MaxBackoff:  30 * time.Second,
+	}
+}
+
+// idempotentMethods lists the methods other than getX methods
+// that have the same effect however many times they're called.
+var idempotentMethods = map[string]bool{
+	"setWebhook":             true,
+	"deleteWebhook":          true,
+	"editMessageText":        true,
+	"editMessageCaption":     true,
+	"editMessageMedia":       true,
+	"editMessageReplyMarkup": true,
+
+	"banChatMember":                   true,
+	"kickChatMember":                  true,
+	"unbanChatMember":                 true,
+	"restrictChatMember":              true,
+	"promoteChatMember":               true,
+	"setChatAdministratorCustomTitle": true,
+	"setChatPermissions":              true,
+}
+
+// isIdempotent reports whether calling the method more than once has the same effect as calling it once.
+func isIdempotent(methodName string) bool {
+	return strings.HasPrefix(methodName, "get") || idempotentMethods[methodName]
+}
+
+// wrap returns an Invoker retrying the requests sent with next according to the policy.
+func (policy RetryPolicy) wrap(next Invoker) Invoker {
+	return func(ctx context.Context, methodName string, params *Params) (*Response, error) {
+		canRetry := (policy.RetryNonIdempotent || isIdempotent(methodName)) && !params.hasReaderUpload()
+		backoff := policy.MinBackoff
+
+		for attempt := 1; ; attempt++ {
+			resp, err := next(ctx, methodName, params)
+			if err == nil || !canRetry || attempt >= policy.MaxAttempts || ctx.Err() != nil {
+				return resp, err
+			}
+
+			wait, ok := policy.wait(err, backoff)
+			if !ok {
+				return resp, err
+			}
+			if backoff *= 2; backoff > policy.MaxBackoff {
+				backoff = policy.MaxBackoff
+			}
+
+			if err := sleepContext(ctx, wait); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// wait returns how long to wait before retrying a request failed with err
+// and whether the request should be retried at all.
+func (policy RetryPolicy) wait(err error, backoff time.Duration) (time.Duration, bool) {
+	var botError *BotError
+	if errors.As(err, &botError) {
+		switch {
+		case botError.Code == http.StatusTooManyRequests:
+			if retryAfter := botError.RetryAfter(); retryAfter > 0 {
+				return retryAfter, true
+			}
+			return backoff, true
+		case botError.Code >= http.StatusInternalServerError:
+			return backoff, true
+		default:
+			return 0, false
+		}
+	}
+
+	var networkError *NetworkError
+	if errors.As(err, &networkError) {
+		return backoff, true
+	}
+
+	return 0, false
+}
+
+// sleepContext pauses for the duration d or until the context is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,90 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrWebhookNotAllowed is returned by Startup.Prepare when removing or replacing a webhook not in its allowlist.
+var ErrWebhookNotAllowed = errors.New("telegram: webhook url not allowed")
+
+// Startup prepares a bot to receive updates either by polling or by webhook, whatever the bot used before.
+// Polling a bot that uses a webhook fails with ErrConflict, as when a bot deployed with a webhook is run locally.
+//
+//	startup := telegram.Startup{
+//		WebhookURL:      os.Getenv("WEBHOOK_URL"), // empty to poll
+//		AllowedWebhooks: []string{"https://staging.example.com/*"},
+//	}
+//	if err := startup.Prepare(ctx, bot); err != nil {
+//		return err
+//	}
+//	if startup.WebhookURL == "" {
+//		return telegram.NewPoller(bot).Run(ctx, dispatcher)
+//	}
+//	return http.ListenAndServe(addr, telegram.NewWebhookHandler(secretToken, dispatcher))
+type Startup struct {
+	// WebhookURL is the url of the webhook to set, or empty to receive updates by polling.
+	WebhookURL string
+
+	// WebhookParams are passed to SetWebhook.
+	//
+	//	Params: SetCertificate, SetIPAddress, SetMaxConnections, SetAllowedUpdates, SetSecretToken.
+	WebhookParams []Param
+
+	// DropPendingUpdates drops the updates waiting to be received when switching from a webhook to polling,
+	// or when setting the webhook.
+	DropPendingUpdates bool
+
+	// AllowedWebhooks are the urls of the webhooks that may be removed, or replaced by WebhookURL.
+	// An url ending with * allows all urls starting with it. When empty, no webhook may be removed.
+	AllowedWebhooks []string
+
+	// AllowAnyWebhook allows removing or replacing any webhook, whatever AllowedWebhooks.
+	AllowAnyWebhook bool
+}
+
+// Prepare checks the current webhook with GetWebhookInfo. When polling, it removes the webhook if any
+// with DeleteWebhook, otherwise it sets WebhookURL with SetWebhook.
+// It returns ErrWebhookNotAllowed without any change when the current webhook would be removed or replaced
+// but isn't in AllowedWebhooks, unless AllowAnyWebhook is set.
+func (s *Startup) Prepare(ctx context.Context, bot *Bot) error {
+	info, err := bot.GetWebhookInfoContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if info.URL != "" && info.URL != s.WebhookURL && !s.allowed(info.URL) {
+		return fmt.Errorf("%w: %s", ErrWebhookNotAllowed, info.URL)
+	}
+
+	var params []Param
+	if s.DropPendingUpdates {
+		params = append(params, SetDropPendingUpdates(true))
+	}
+
+	if s.WebhookURL == "" {
+		if info.URL == "" {
+			return nil
+		}
+		_, err = bot.DeleteWebhookContext(ctx, params...)
+		return err
+	}
+
+	_, err = bot.SetWebhookContext(ctx, s.WebhookURL, append(params, s.WebhookParams...)...)
+	return err
+}
+
+// allowed reports whether the webhook url may be removed or replaced.
+func (s *Startup) allowed(url string) bool {
+	if s.AllowAnyWebhook {
+		return true
+	}
+	for _, allowed := range s.AllowedWebhooks {
+		if url == allowed || strings.HasSuffix(allowed, "*") && strings.HasPrefix(url, strings.TrimSuffix(allowed, "*")) {
+			return true
+		}
+	}
+	return false
+}
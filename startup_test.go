@@ -0,0 +1,124 @@
+package telegram_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/62bot/telegram"
+	"github.com/billyzaelani/is"
+)
+
+// TestStartup tests switching a bot between polling and webhook.
+func TestStartup(t *testing.T) {
+	const (
+		production = "https://example.com/webhook"
+		staging    = "https://staging.example.com/webhook"
+	)
+
+	tests := map[string]struct {
+		webhook     string
+		startup     telegram.Startup
+		wantErr     error
+		wantWebhook string
+		wantCalls   []string
+	}{
+		"polling_without_webhook": {
+			wantCalls: []string{"getWebhookInfo"},
+		},
+		"polling_with_webhook": {
+			webhook:     production,
+			startup:     telegram.Startup{DropPendingUpdates: true},
+			wantErr:     telegram.ErrWebhookNotAllowed,
+			wantWebhook: production,
+			wantCalls:   []string{"getWebhookInfo"},
+		},
+		"polling_with_any_webhook_allowed": {
+			webhook:   staging,
+			startup:   telegram.Startup{DropPendingUpdates: true, AllowAnyWebhook: true},
+			wantCalls: []string{"getWebhookInfo", "deleteWebhook drop_pending_updates=true"},
+		},
+		"polling_with_allowed_webhook": {
+			webhook:   staging,
+			startup:   telegram.Startup{AllowedWebhooks: []string{"https://staging.example.com/*"}},
+			wantCalls: []string{"getWebhookInfo", "deleteWebhook"},
+		},
+		"polling_with_not_allowed_webhook": {
+			webhook:     production,
+			startup:     telegram.Startup{AllowedWebhooks: []string{"https://staging.example.com/*"}},
+			wantErr:     telegram.ErrWebhookNotAllowed,
+			wantWebhook: production,
+			wantCalls:   []string{"getWebhookInfo"},
+		},
+		"webhook": {
+			startup: telegram.Startup{
+				WebhookURL:    production,
+				WebhookParams: []telegram.Param{telegram.SetSecretToken("secret")},
+			},
+			wantWebhook: production,
+			wantCalls:   []string{"getWebhookInfo", "setWebhook url=" + production + " secret_token=secret"},
+		},
+		"webhook_already_set": {
+			webhook:     production,
+			startup:     telegram.Startup{WebhookURL: production, AllowedWebhooks: []string{staging}},
+			wantWebhook: production,
+			wantCalls:   []string{"getWebhookInfo", "setWebhook url=" + production},
+		},
+		"webhook_replacing_not_allowed_webhook": {
+			webhook:     staging,
+			startup:     telegram.Startup{WebhookURL: production, AllowedWebhooks: []string{production}},
+			wantErr:     telegram.ErrWebhookNotAllowed,
+			wantWebhook: staging,
+			wantCalls:   []string{"getWebhookInfo"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			is := is.New(t)
+
+			webhook := tt.webhook
+			var calls []string
+			client := newTestClient(func(methodName string, params url.Values) *http.Response {
+				var result interface{} = true
+				call := methodName
+				switch methodName {
+				case "getMe":
+					return newHTTPResponse(authorizedCase.StatusCode, authorizedCase.Body)
+				case "getWebhookInfo":
+					result = telegram.WebhookInfo{URL: webhook}
+				case "setWebhook":
+					webhook = params.Get("url")
+					call += " url=" + webhook
+					if token := params.Get("secret_token"); token != "" {
+						call += " secret_token=" + token
+					}
+				case "deleteWebhook":
+					webhook = ""
+					if drop := params.Get("drop_pending_updates"); drop != "" {
+						call += " drop_pending_updates=" + drop
+					}
+				}
+				calls = append(calls, call)
+
+				b, _ := json.Marshal(result)
+				body, _ := json.Marshal(telegram.Response{OK: true, Result: b})
+				return newHTTPResponse(http.StatusOK, body)
+			})
+			bot, err := telegram.NewBot(validTestToken, telegram.SetClient(client), telegram.SetLazy())
+			is.NoError(err)
+
+			err = tt.startup.Prepare(context.Background(), bot)
+			if tt.wantErr != nil {
+				is.Error(err, tt.wantErr)
+			} else {
+				is.NoError(err)
+			}
+
+			is.Equal(webhook, tt.wantWebhook)
+			is.Equal(calls, tt.wantCalls)
+		})
+	}
+}
@@ -1,5 +1,14 @@
 package telegram
 
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
 // User represents a Telegram user or bot.
 //
 // https://core.telegram.org/bots/api#user.
@@ -38,6 +47,14 @@ type Chat struct {
 	Location         *ChatLocation    `json:"location,omitempty"`            // Optional.
 }
 
+// Types of chat, the Type of a Chat.
+const (
+	ChatTypePrivate    = "private"
+	ChatTypeGroup      = "group"
+	ChatTypeSupergroup = "supergroup"
+	ChatTypeChannel    = "channel"
+)
+
 // Message represents a message.
 //
 // https://core.telegram.org/bots/api#message
@@ -200,9 +217,9 @@ type VideoNote struct {
 type Voice struct {
 	FileID       string `json:"file_id"`
 	FileUniqueID string `json:"file_unique_id"`
-	Duration     string `json:"duration"`
+	Duration     int    `json:"duration"`
 	MIMEType     string `json:"mime_type,omitempty"` // Optional.
-	FileSize     string `json:"file_size,omitempty"` // Optional.
+	FileSize     int    `json:"file_size,omitempty"` // Optional.
 }
 
 // Contact represents a phone contact.
@@ -310,7 +327,7 @@ type UserProfilePhotos struct {
 type File struct {
 	FileID       string `json:"file_id"`
 	FileUniqueID string `json:"file_unique_id"`
-	FileSize     string `json:"file_size,omitempty"` // Optional.
+	FileSize     int    `json:"file_size,omitempty"` // Optional.
 	FilePath     string `json:"file_path,omitempty"` // Optional.
 }
 
@@ -413,6 +430,17 @@ type ForceReply struct {
 	Selective  bool `json:"selective,omitempty"` // Optional.
 }
 
+// ReplyMarkup is the additional interface options of a message, see SetReplyMarkup.
+// It's implemented by InlineKeyboardMarkup, ReplyKeyboardMarkup, ReplyKeyboardRemove and ForceReply only.
+type ReplyMarkup interface {
+	replyMarkup()
+}
+
+func (*InlineKeyboardMarkup) replyMarkup() {}
+func (*ReplyKeyboardMarkup) replyMarkup()  {}
+func (*ReplyKeyboardRemove) replyMarkup()  {}
+func (*ForceReply) replyMarkup()           {}
+
 // ChatPhoto represents a chat photo.
 //
 // https://core.telegram.org/bots/api#chatphoto
@@ -487,7 +515,7 @@ type ResponseParameters struct {
 	RetryAfter      int `json:"retry_after,omitempty"`        // Optional.
 }
 
-// InputMedia represents the content of a media message to be sent. It should be one of
+// InputMedia represents the content of a media message to be sent. It's one of
 //
 //  InputMediaAnimation
 //  InputMediaDocument
@@ -495,15 +523,20 @@ type ResponseParameters struct {
 //  InputMediaPhoto
 //  InputMediaVideo
 //
+// The type of the media is set automatically. A media or a thumbnail to upload is sent
+// in its own part of the request and referenced as attach://<name>.
+//
 // https://core.telegram.org/bots/api#inputmedia
-// TODO: should InputMedia be a type?
+type InputMedia interface {
+	mediaType() string
+	encodeMedia(params *Params, name string) (json.RawMessage, error)
+}
 
 // InputMediaPhoto represents a photo to be sent.
 //
 // https://core.telegram.org/bots/api#inputmediaphoto
 type InputMediaPhoto struct {
-	Type            string           `json:"type"`
-	Media           string           `json:"media"`
+	Media           InputFile        `json:"-"`
 	Caption         string           `json:"caption,omitempty"`          // Optional.
 	ParseMode       string           `json:"parse_mode,omitempty"`       // Optional.
 	CaptionEntities []*MessageEntity `json:"caption_entities,omitempty"` // Optional.
@@ -513,9 +546,8 @@ type InputMediaPhoto struct {
 //
 // https://core.telegram.org/bots/api#inputmediavideo
 type InputMediaVideo struct {
-	Type  string `json:"type"`
-	Media string `json:"media"`
-	// Thumb *InputFile or string `json:"thumb,omitempty"` // TODO: handle the inconsistent type
+	Media             InputFile        `json:"-"`
+	Thumb             InputFile        `json:"-"`                            // Optional, uploaded only.
 	Caption           string           `json:"caption,omitempty"`            // Optional.
 	ParseMode         string           `json:"parse_mode,omitempty"`         // Optional.
 	CaptionEntities   []*MessageEntity `json:"caption_entities,omitempty"`   // Optional.
@@ -529,9 +561,8 @@ type InputMediaVideo struct {
 //
 // https://core.telegram.org/bots/api#inputmediaanimation
 type InputMediaAnimation struct {
-	Type  string `json:"type"`
-	Media string `json:"media"`
-	// Thumb *InputFile or string `json:"thumb,omitempty"` // TODO: handle the inconsistent type
+	Media           InputFile        `json:"-"`
+	Thumb           InputFile        `json:"-"`                          // Optional, uploaded only.
 	Caption         string           `json:"caption,omitempty"`          // Optional.
 	ParseMode       string           `json:"parse_mode,omitempty"`       // Optional.
 	CaptionEntities []*MessageEntity `json:"caption_entities,omitempty"` // Optional.
@@ -544,9 +575,8 @@ type InputMediaAnimation struct {
 //
 // https://core.telegram.org/bots/api#inputmediaaudio
 type InputMediaAudio struct {
-	Type  string `json:"type"`
-	Media string `json:"media"`
-	// Thumb *InputFile or string `json:"thumb,omitempty"` // TODO: handle the inconsistent type
+	Media           InputFile        `json:"-"`
+	Thumb           InputFile        `json:"-"`                          // Optional, uploaded only.
 	Caption         string           `json:"caption,omitempty"`          // Optional.
 	ParseMode       string           `json:"parse_mode,omitempty"`       // Optional.
 	CaptionEntities []*MessageEntity `json:"caption_entities,omitempty"` // Optional.
@@ -559,17 +589,150 @@ type InputMediaAudio struct {
 //
 // https://core.telegram.org/bots/api#inputmediadocument
 type InputMediaDocument struct {
-	Type  string `json:"type"`
-	Media string `json:"media"`
-	// Thumb *InputFile or string `json:"thumb,omitempty"` // TODO: handle the inconsistent type
+	Media                       InputFile        `json:"-"`
+	Thumb                       InputFile        `json:"-"`                                        // Optional, uploaded only.
 	Caption                     string           `json:"caption,omitempty"`                        // Optional.
 	ParseMode                   string           `json:"parse_mode,omitempty"`                     // Optional.
 	CaptionEntities             []*MessageEntity `json:"caption_entities,omitempty"`               // Optional.
 	DisableContentTypeDetection bool             `json:"disable_content_type_detection,omitempty"` // Optional.
 }
 
+func (InputMediaPhoto) mediaType() string     { return "photo" }
+func (InputMediaVideo) mediaType() string     { return "video" }
+func (InputMediaAnimation) mediaType() string { return "animation" }
+func (InputMediaAudio) mediaType() string     { return "audio" }
+func (InputMediaDocument) mediaType() string  { return "document" }
+
+func (m InputMediaPhoto) encodeMedia(params *Params, name string) (json.RawMessage, error) {
+	return encodeInputMedia(params, name, m, m.Media, InputFile{})
+}
+
+func (m InputMediaVideo) encodeMedia(params *Params, name string) (json.RawMessage, error) {
+	return encodeInputMedia(params, name, m, m.Media, m.Thumb)
+}
+
+func (m InputMediaAnimation) encodeMedia(params *Params, name string) (json.RawMessage, error) {
+	return encodeInputMedia(params, name, m, m.Media, m.Thumb)
+}
+
+func (m InputMediaAudio) encodeMedia(params *Params, name string) (json.RawMessage, error) {
+	return encodeInputMedia(params, name, m, m.Media, m.Thumb)
+}
+
+func (m InputMediaDocument) encodeMedia(params *Params, name string) (json.RawMessage, error) {
+	return encodeInputMedia(params, name, m, m.Media, m.Thumb)
+}
+
+// encodeInputMedia returns the JSON object of the media with its type, adding the files to upload to params
+// as the parts name and name_thumb.
+func encodeInputMedia(params *Params, name string, m InputMedia, media, thumb InputFile) (json.RawMessage, error) {
+	if media.isZero() {
+		return nil, fmt.Errorf("telegram: %s media %s is not set", m.mediaType(), name)
+	}
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(b, &object); err != nil {
+		return nil, err
+	}
+
+	if object["type"], err = json.Marshal(m.mediaType()); err != nil {
+		return nil, err
+	}
+	if object["media"], err = json.Marshal(params.attach(name, media)); err != nil {
+		return nil, err
+	}
+	if !thumb.isZero() {
+		if object["thumb"], err = json.Marshal(params.attach(name+"_thumb", thumb)); err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(object)
+}
+
 // InputFile represents the contents of a file to be uploaded.
 // Must be posted using multipart/form-data in the usual way that files are uploaded via the browser.
+// An InputFile is created with one of
+//
+//  InputFileID     // a file that exists on the Telegram servers.
+//  InputFileURL    // an HTTP URL for Telegram to get a file from the Internet.
+//  InputFilePath   // a local file to upload.
+//  InputFileLocal  // a local file read by a local Bot API server, uploaded otherwise.
+//  InputFileReader // an io.Reader to upload.
 //
 // https://core.telegram.org/bots/api#inputfile
-// TODO: should InputFile be a type?
+type InputFile struct {
+	id     string // file_id or HTTP URL.
+	path   string
+	name   string
+	reader io.Reader
+	local  bool
+}
+
+// InputFileID returns an InputFile of a file that exists on the Telegram servers.
+func InputFileID(fileID string) InputFile {
+	return InputFile{id: fileID}
+}
+
+// InputFileURL returns an InputFile of an HTTP URL, Telegram will get the file from the Internet.
+func InputFileURL(url string) InputFile {
+	return InputFile{id: url}
+}
+
+// InputFilePath returns an InputFile of a local file to upload.
+func InputFilePath(path string) InputFile {
+	return InputFile{path: path, name: filepath.Base(path)}
+}
+
+// InputFileLocal returns an InputFile of a local file. When the bot uses a local Bot API server (see SetLocalMode)
+// the file is not uploaded, the server reads it from its absolute path given as a file:// URI instead.
+// Otherwise the file is uploaded like InputFilePath.
+func InputFileLocal(path string) InputFile {
+	return InputFile{path: path, name: filepath.Base(path), local: true}
+}
+
+// InputFileReader returns an InputFile to upload from r with the given file name.
+// The reader is read once, when the request is sent.
+func InputFileReader(name string, r io.Reader) InputFile {
+	return InputFile{name: name, reader: r}
+}
+
+// isZero reports whether the file is the zero InputFile, not set.
+func (f InputFile) isZero() bool {
+	return f.id == "" && !f.isUpload()
+}
+
+// isUpload reports whether the file has to be uploaded.
+func (f InputFile) isUpload() bool {
+	return f.path != "" || f.reader != nil
+}
+
+// size returns the size of the file to upload, or -1 when it's unknown until the file is read.
+func (f InputFile) size() (int64, error) {
+	if f.reader == nil {
+		fi, err := os.Stat(f.path)
+		if err != nil {
+			return 0, err
+		}
+		return fi.Size(), nil
+	}
+
+	// bytes.Reader, bytes.Buffer and strings.Reader know the length of their unread portion.
+	if r, ok := f.reader.(interface{ Len() int }); ok {
+		return int64(r.Len()), nil
+	}
+	return -1, nil
+}
+
+// open returns the content of the file to upload.
+func (f InputFile) open() (io.ReadCloser, error) {
+	if f.reader != nil {
+		return ioutil.NopCloser(f.reader), nil
+	}
+	return os.Open(f.path)
+}
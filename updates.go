@@ -1,6 +1,9 @@
 package telegram
 
-import "encoding/json"
+import (
+	"context"
+	"encoding/json"
+)
 
 // Update represents an incoming update.
 // At most one of the optional parameters can be present in any given update.
@@ -19,20 +22,171 @@ type Update struct {
 	PreCheckoutQuery   *PreCheckoutQuery   `json:"pre_checkout_query,omitempty"`   // Optional.
 	Poll               *Poll               `json:"poll,omitempty"`                 // Optional.
 	PollAnswer         *PollAnswer         `json:"poll_answer,omitempty"`          // Optional.
+
+	raw *json.RawMessage // The update as received, kept only for a Recorder.
+}
+
+// Raw returns the JSON of the update as received from Telegram, including the fields unknown to the package.
+// An update is kept as received only when a Recorder is the handler of a Poller or a WebhookHandler,
+// directly or through a WorkerPool, otherwise Raw returns the update encoded to JSON.
+func (u Update) Raw() (json.RawMessage, error) {
+	if u.raw != nil {
+		return *u.raw, nil
+	}
+	return json.Marshal(u)
+}
+
+// decodeUpdate decodes the JSON of an update, keeping it as received when keepRaw is set, see Raw.
+func decodeUpdate(b []byte, keepRaw bool) (Update, error) {
+	var update Update
+	if err := json.Unmarshal(b, &update); err != nil {
+		return Update{}, err
+	}
+	if keepRaw {
+		raw := append(json.RawMessage(nil), b...)
+		update.raw = &raw
+	}
+	return update, nil
+}
+
+// UpdateType is the kind of an Update, named after the field of the update that is set.
+// It's also used to list the kinds of update to receive with SetAllowedUpdates.
+type UpdateType string
+
+// All kinds of update.
+const (
+	UpdateMessage            UpdateType = "message"
+	UpdateEditedMessage      UpdateType = "edited_message"
+	UpdateChannelPost        UpdateType = "channel_post"
+	UpdateEditedChannelPost  UpdateType = "edited_channel_post"
+	UpdateInlineQuery        UpdateType = "inline_query"
+	UpdateChosenInlineResult UpdateType = "chosen_inline_result"
+	UpdateCallbackQuery      UpdateType = "callback_query"
+	UpdateShippingQuery      UpdateType = "shipping_query"
+	UpdatePreCheckoutQuery   UpdateType = "pre_checkout_query"
+	UpdatePoll               UpdateType = "poll"
+	UpdatePollAnswer         UpdateType = "poll_answer"
+)
+
+// Type returns the kind of the update, or an empty UpdateType when the update is of a kind unknown to the package.
+func (u Update) Type() UpdateType {
+	switch {
+	case u.Message != nil:
+		return UpdateMessage
+	case u.EditedMessage != nil:
+		return UpdateEditedMessage
+	case u.ChannelPost != nil:
+		return UpdateChannelPost
+	case u.EditedChannelPost != nil:
+		return UpdateEditedChannelPost
+	case u.InlineQuery != nil:
+		return UpdateInlineQuery
+	case u.ChosenInlineResult != nil:
+		return UpdateChosenInlineResult
+	case u.CallbackQuery != nil:
+		return UpdateCallbackQuery
+	case u.ShippingQuery != nil:
+		return UpdateShippingQuery
+	case u.PreCheckoutQuery != nil:
+		return UpdatePreCheckoutQuery
+	case u.Poll != nil:
+		return UpdatePoll
+	case u.PollAnswer != nil:
+		return UpdatePollAnswer
+	}
+	return ""
+}
+
+// EffectiveMessage returns the message of the update whichever field it's in,
+// a new, edited or channel message, or the message with the button of a callback query.
+// It returns nil when the update has no message.
+// The method isn't named Message as it would clash with the Message field.
+func (u Update) EffectiveMessage() *Message {
+	switch {
+	case u.Message != nil:
+		return u.Message
+	case u.EditedMessage != nil:
+		return u.EditedMessage
+	case u.ChannelPost != nil:
+		return u.ChannelPost
+	case u.EditedChannelPost != nil:
+		return u.EditedChannelPost
+	case u.CallbackQuery != nil:
+		return u.CallbackQuery.Message
+	}
+	return nil
+}
+
+// Chat returns the chat the update comes from, or nil when the update isn't bound to a chat,
+// such as an inline query or a callback query of an inline message.
+func (u Update) Chat() *Chat {
+	if message := u.EffectiveMessage(); message != nil {
+		return message.Chat
+	}
+	return nil
+}
+
+// Sender returns the user that caused the update, or nil when there's none,
+// such as a channel post or a poll state.
+func (u Update) Sender() *User {
+	switch {
+	case u.InlineQuery != nil:
+		return u.InlineQuery.From
+	case u.ChosenInlineResult != nil:
+		return u.ChosenInlineResult.From
+	case u.CallbackQuery != nil:
+		return u.CallbackQuery.From
+	case u.ShippingQuery != nil:
+		return u.ShippingQuery.From
+	case u.PreCheckoutQuery != nil:
+		return u.PreCheckoutQuery.From
+	case u.PollAnswer != nil:
+		return u.PollAnswer.User
+	}
+	if message := u.EffectiveMessage(); message != nil {
+		return message.From
+	}
+	return nil
+}
+
+// UpdateHandler responds to an Update.
+type UpdateHandler interface {
+	HandleUpdate(ctx context.Context, update Update) error
+}
+
+// UpdateHandlerFunc is an adapter to allow the use of ordinary functions as UpdateHandler.
+type UpdateHandlerFunc func(ctx context.Context, update Update) error
+
+// HandleUpdate calls f(ctx, update).
+func (f UpdateHandlerFunc) HandleUpdate(ctx context.Context, update Update) error {
+	return f(ctx, update)
+}
+
+// ChannelHandler returns an UpdateHandler sending the updates to ch.
+// HandleUpdate blocks until the update is sent or the context is done.
+func ChannelHandler(ch chan<- Update) UpdateHandler {
+	return UpdateHandlerFunc(func(ctx context.Context, update Update) error {
+		select {
+		case ch <- update:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
 }
 
 // WebhookInfo contains information about the current status of a webhook.
 //
 // https://core.telegram.org/bots/api#webhookinfo
 type WebhookInfo struct {
-	URL                  string   `json:"url"`
-	HasCustomCertificate bool     `json:"has_custom_certificate"`
-	PendingUpdateCount   int      `json:"pending_update_count"`
-	IPAddress            string   `json:"ip_address,omitempty"`         // Optional.
-	LastErrorDate        int      `json:"last_error_date,omitempty"`    // Optional.
-	LastErrorMessage     string   `json:"last_error_message,omitempty"` // Optional.
-	MaxConnections       int      `json:"max_connections,omitempty"`    // Optional.
-	AllowedUpdates       []string `json:"allowed_updates,omitempty"`    // Optional.
+	URL                  string       `json:"url"`
+	HasCustomCertificate bool         `json:"has_custom_certificate"`
+	PendingUpdateCount   int          `json:"pending_update_count"`
+	IPAddress            string       `json:"ip_address,omitempty"`         // Optional.
+	LastErrorDate        int          `json:"last_error_date,omitempty"`    // Optional.
+	LastErrorMessage     string       `json:"last_error_message,omitempty"` // Optional.
+	MaxConnections       int          `json:"max_connections,omitempty"`    // Optional.
+	AllowedUpdates       []UpdateType `json:"allowed_updates,omitempty"`    // Optional.
 }
 
 // GetUpdates returns a slice of Update.
@@ -42,15 +196,43 @@ type WebhookInfo struct {
 //
 // https://core.telegram.org/bots/api#getupdates
 func (bot *Bot) GetUpdates(params ...Param) ([]Update, error) {
-	urlVal := resolveParam(params)
-	resp, err := bot.MakeRequest("getUpdates", urlVal)
+	return bot.GetUpdatesContext(context.Background(), params...)
+}
+
+// GetUpdatesContext is like GetUpdates but with a context.
+// Cancelling the context aborts a pending long polling request.
+func (bot *Bot) GetUpdatesContext(ctx context.Context, params ...Param) ([]Update, error) {
+	return bot.getUpdates(ctx, false, params)
+}
+
+// getUpdates is like GetUpdatesContext, keeping the updates as received when keepRaw is set, see Update.Raw.
+func (bot *Bot) getUpdates(ctx context.Context, keepRaw bool, params []Param) ([]Update, error) {
+	p := resolveParam(params)
+	resp, err := bot.makeRequest(ctx, "getUpdates", p)
 	if err != nil {
 		return nil, err
 	}
 
 	var updates []Update
-	if err := json.NewDecoder(resp).Decode(&updates); err != nil {
-		return nil, err
+	if !keepRaw {
+		if err := resp.decode(&updates); err != nil {
+			return nil, err
+		}
+	} else {
+		var raws []json.RawMessage
+		if err := resp.decode(&raws); err != nil {
+			return nil, err
+		}
+		updates = make([]Update, len(raws))
+		for i, raw := range raws {
+			if updates[i], err = decodeUpdate(raw, true); err != nil {
+				return nil, &DecodeError{Method: "getUpdates", Err: err}
+			}
+		}
+	}
+
+	if bot.chatMigrator != nil {
+		bot.chatMigrator.observe(updates)
 	}
 
 	return updates, nil
@@ -60,19 +242,24 @@ func (bot *Bot) GetUpdates(params ...Param) ([]Update, error) {
 // Whenever there is an update for the bot, we will send an HTTPS POST request to the specified url, containing a JSON-serialized Update.
 // In case of an unsuccessful request, we will give up after a reasonable amount of attempts. Returns True on success.
 //
-//  Params: SetCertificate (TODO), SetIPAddress, SetMaxConnections, SetAllowedUpdates, SetDropPendingUpdates.
+//  Params: SetCertificate, SetIPAddress, SetMaxConnections, SetAllowedUpdates, SetDropPendingUpdates, SetSecretToken.
 //
 // https://core.telegram.org/bots/api#setwebhook
 func (bot *Bot) SetWebhook(url string, params ...Param) (bool, error) {
+	return bot.SetWebhookContext(context.Background(), url, params...)
+}
+
+// SetWebhookContext is like SetWebhook but with a context.
+func (bot *Bot) SetWebhookContext(ctx context.Context, url string, params ...Param) (bool, error) {
 	params = append(params, setParamString("url", url))
-	urlVal := resolveParam(params)
-	resp, err := bot.MakeRequest("setWebhook", urlVal)
+	p := resolveParam(params)
+	resp, err := bot.makeRequest(ctx, "setWebhook", p)
 	if err != nil {
 		return false, err
 	}
 
 	var ok bool
-	if err := json.NewDecoder(resp).Decode(&ok); err != nil {
+	if err := resp.decode(&ok); err != nil {
 		return false, err
 	}
 
@@ -85,14 +272,19 @@ func (bot *Bot) SetWebhook(url string, params ...Param) (bool, error) {
 //
 // https://core.telegram.org/bots/api#deletewebhook
 func (bot *Bot) DeleteWebhook(params ...Param) (bool, error) {
-	urlVal := resolveParam(params)
-	resp, err := bot.MakeRequest("deleteWebhook", urlVal)
+	return bot.DeleteWebhookContext(context.Background(), params...)
+}
+
+// DeleteWebhookContext is like DeleteWebhook but with a context.
+func (bot *Bot) DeleteWebhookContext(ctx context.Context, params ...Param) (bool, error) {
+	p := resolveParam(params)
+	resp, err := bot.makeRequest(ctx, "deleteWebhook", p)
 	if err != nil {
 		return false, err
 	}
 
 	var ok bool
-	if err := json.NewDecoder(resp).Decode(&ok); err != nil {
+	if err := resp.decode(&ok); err != nil {
 		return false, err
 	}
 
@@ -104,13 +296,18 @@ func (bot *Bot) DeleteWebhook(params ...Param) (bool, error) {
 //
 // https://core.telegram.org/bots/api#getwebhookinfo
 func (bot *Bot) GetWebhookInfo() (WebhookInfo, error) {
-	resp, err := bot.MakeRequest("getWebhookInfo", nil)
+	return bot.GetWebhookInfoContext(context.Background())
+}
+
+// GetWebhookInfoContext is like GetWebhookInfo but with a context.
+func (bot *Bot) GetWebhookInfoContext(ctx context.Context) (WebhookInfo, error) {
+	resp, err := bot.MakeRequestContext(ctx, "getWebhookInfo", nil)
 	if err != nil {
 		return WebhookInfo{}, err
 	}
 
 	var webhookInfo WebhookInfo
-	if err := json.NewDecoder(resp).Decode(&webhookInfo); err != nil {
+	if err := resp.decode(&webhookInfo); err != nil {
 		return WebhookInfo{}, err
 	}
 
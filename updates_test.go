@@ -1,7 +1,10 @@
 package telegram_test
 
 import (
+	"encoding/json"
 	"net/http"
+	"strings"
+	"testing"
 
 	"github.com/62bot/telegram"
 	"github.com/billyzaelani/is"
@@ -68,7 +71,14 @@ func setWebhookOK(is *is.Is, bot *telegram.Bot) {
 	is.True(ok) // setWebhook should be ok
 }
 
-// TODO: func setWebhookWithCertificate(is *is.Is, bot *telegram.Bot) {}
+func setWebhookWithCertificate(is *is.Is, bot *telegram.Bot) {
+	ok, err := bot.SetWebhook("https://example.com",
+		telegram.SetCertificate(telegram.InputFileReader("cert.pem", strings.NewReader("certificate"))),
+	)
+	is.NoError(err)
+
+	is.True(ok)
+}
 
 func setWebhookWithParams(is *is.Is, bot *telegram.Bot) {
 	ok, err := bot.SetWebhook("https://example.com",
@@ -82,6 +92,15 @@ func setWebhookWithParams(is *is.Is, bot *telegram.Bot) {
 	is.True(ok)
 }
 
+func setWebhookWithSecretToken(is *is.Is, bot *telegram.Bot) {
+	ok, err := bot.SetWebhook("https://example.com",
+		telegram.SetSecretToken("my-secret_token"),
+	)
+	is.NoError(err)
+
+	is.True(ok)
+}
+
 func setWebhookErrorNotHTTPS(is *is.Is, bot *telegram.Bot) {
 	_, err := bot.SetWebhook("http://example.com")
 	var botError *telegram.BotError
@@ -104,3 +123,70 @@ func getWebhookInfoOK(is *is.Is, bot *telegram.Bot) {
 
 	is.Equal(webhookInfo.URL, "https://example.com")
 }
+
+// TestUpdateType tests finding the kind, the chat, the sender and the message of an update.
+func TestUpdateType(t *testing.T) {
+	tests := map[string]struct {
+		update      string
+		wantType    telegram.UpdateType
+		wantChatID  int
+		wantUserID  int
+		wantMessage bool
+	}{
+		"message": {
+			update:   `{"update_id":1,"message":{"message_id":1,"from":{"id":2},"chat":{"id":3,"type":"private"}}}`,
+			wantType: telegram.UpdateMessage, wantChatID: 3, wantUserID: 2, wantMessage: true,
+		},
+		"edited_message": {
+			update:   `{"update_id":1,"edited_message":{"message_id":1,"from":{"id":2},"chat":{"id":3,"type":"private"}}}`,
+			wantType: telegram.UpdateEditedMessage, wantChatID: 3, wantUserID: 2, wantMessage: true,
+		},
+		"channel_post": {
+			update:   `{"update_id":1,"channel_post":{"message_id":1,"chat":{"id":-100,"type":"channel"}}}`,
+			wantType: telegram.UpdateChannelPost, wantChatID: -100, wantMessage: true,
+		},
+		"inline_query": {
+			update:   `{"update_id":1,"inline_query":{"id":"1","from":{"id":2},"query":"q","offset":""}}`,
+			wantType: telegram.UpdateInlineQuery, wantUserID: 2,
+		},
+		"callback_query": {
+			update:   `{"update_id":1,"callback_query":{"id":"1","from":{"id":2},"message":{"message_id":1,"from":{"id":9},"chat":{"id":3,"type":"private"}},"chat_instance":"c"}}`,
+			wantType: telegram.UpdateCallbackQuery, wantChatID: 3, wantUserID: 2, wantMessage: true,
+		},
+		"inline_callback_query": {
+			update:   `{"update_id":1,"callback_query":{"id":"1","from":{"id":2},"inline_message_id":"m","chat_instance":"c"}}`,
+			wantType: telegram.UpdateCallbackQuery, wantUserID: 2,
+		},
+		"poll_answer": {
+			update:   `{"update_id":1,"poll_answer":{"poll_id":"1","user":{"id":2},"option_ids":[0]}}`,
+			wantType: telegram.UpdatePollAnswer, wantUserID: 2,
+		},
+		"unknown": {
+			update: `{"update_id":1,"chat_member":{}}`,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			is := is.New(t)
+
+			var update telegram.Update
+			is.NoError(json.Unmarshal([]byte(tt.update), &update))
+
+			is.Equal(update.Type(), tt.wantType)
+			is.Equal(update.EffectiveMessage() != nil, tt.wantMessage)
+
+			chatID := 0
+			if chat := update.Chat(); chat != nil {
+				chatID = chat.ID
+			}
+			is.Equal(chatID, tt.wantChatID)
+
+			userID := 0
+			if user := update.Sender(); user != nil {
+				userID = user.ID
+			}
+			is.Equal(userID, tt.wantUserID)
+		})
+	}
+}
@@ -0,0 +1,355 @@
+package telegram
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// SecretTokenHeader is the header holding the secret token set with SetSecretToken.
+const SecretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// DefaultMaxBodySize is the default maximum size of a webhook request body.
+const DefaultMaxBodySize = 1 << 20 // 1 MB
+
+// DefaultReplyTimeout is the default time a WebhookHandler waits for the Call made in reply to an update.
+const DefaultReplyTimeout = 3 * time.Second
+
+// DefaultMaxConcurrency is the default number of updates a WebhookHandler handles at once.
+const DefaultMaxConcurrency = 64
+
+// ErrWebhookClosed is the error of the requests received by a WebhookHandler once it's shut down.
+var ErrWebhookClosed = errors.New("telegram: webhook: handler shut down")
+
+// Call is a Bot API method call, such as the reply to a webhook update.
+type Call struct {
+	Method string
+	Params []Param
+}
+
+// NewCall returns the Call of the method with the params.
+//
+//	telegram.NewCall("unpinAllChatMessages", telegram.SetChatID(chatID))
+func NewCall(methodName string, params ...Param) *Call {
+	return &Call{Method: methodName, Params: params}
+}
+
+// ReplyHandler responds to an Update, returning the Call to make in reply or nil for no reply.
+type ReplyHandler interface {
+	HandleUpdateReply(ctx context.Context, update Update) (*Call, error)
+}
+
+// ReplyHandlerFunc is an adapter to allow the use of ordinary functions as ReplyHandler.
+type ReplyHandlerFunc func(ctx context.Context, update Update) (*Call, error)
+
+// HandleUpdateReply calls f(ctx, update).
+func (f ReplyHandlerFunc) HandleUpdateReply(ctx context.Context, update Update) (*Call, error) {
+	return f(ctx, update)
+}
+
+// WebhookHandler is an http.Handler receiving the updates sent by Telegram to the webhook set with SetWebhook.
+//
+// The handler answers Telegram as soon as the update is decoded and calls the UpdateHandler in a new goroutine,
+// so a slow UpdateHandler doesn't make the updates pile up on the Telegram side.
+// At most MaxConcurrency updates are handled at once, a request waits for a running update to be handled
+// before being answered, which holds back Telegram. The updates are handled concurrently,
+// so their order isn't preserved; use a WorkerPool as the UpdateHandler to keep the order of the updates of a chat.
+//
+// The handlers are called with a context cancelled by Shutdown, which waits for them:
+//
+//	srv := &http.Server{Addr: addr, Handler: webhook}
+//	srv.RegisterOnShutdown(func() { webhook.Shutdown(ctx) })
+type WebhookHandler struct {
+	MaxBodySize    int64           // Maximum size of the request body, larger requests are rejected.
+	ReplyTimeout   time.Duration   // Time to wait for the Call of a ReplyHandler before answering Telegram.
+	MaxConcurrency int             // Maximum number of updates handled at once, set before the first request.
+	ErrorHandler   func(err error) // Called with the errors of the handler, the replies and the rejected requests, if not nil.
+
+	secretToken  string
+	handler      UpdateHandler
+	bot          *Bot
+	replyHandler ReplyHandler
+
+	ctx     context.Context // Context of the handlers, cancelled by Shutdown.
+	cancel  context.CancelFunc
+	running sync.WaitGroup
+	once    sync.Once
+	slots   chan struct{}
+
+	mu     sync.RWMutex // guards closed against adding to running.
+	closed bool
+}
+
+// NewWebhookHandler returns a WebhookHandler calling the handler for every update.
+// Requests without the secretToken given to SetWebhook with SetSecretToken are rejected,
+// an empty secretToken accepts every request.
+func NewWebhookHandler(secretToken string, handler UpdateHandler) *WebhookHandler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &WebhookHandler{
+		MaxBodySize:    DefaultMaxBodySize,
+		MaxConcurrency: DefaultMaxConcurrency,
+		secretToken:    secretToken,
+		handler:        handler,
+		ctx:            ctx,
+		cancel:         cancel,
+	}
+}
+
+// NewWebhookReplyHandler returns a WebhookHandler calling the handler for every update
+// and making the Call returned by the handler in reply.
+//
+// The Call is sent to Telegram in the body of the webhook response, which saves a request.
+// Since the result of the Call is not known, it's suitable for calls like sendMessage whose result is not needed.
+// The chat of the Call is rewritten when it's known to be migrated, see SetChatMigration.
+// The Call is made by the bot as a regular request instead when it uploads a file,
+// when the bot has interceptors, is a dry run or rate limits the Call,
+// or when the handler returns after the ReplyTimeout, once Telegram has been answered.
+func NewWebhookReplyHandler(bot *Bot, secretToken string, handler ReplyHandler) *WebhookHandler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &WebhookHandler{
+		MaxBodySize:    DefaultMaxBodySize,
+		ReplyTimeout:   DefaultReplyTimeout,
+		MaxConcurrency: DefaultMaxConcurrency,
+		secretToken:    secretToken,
+		bot:            bot,
+		replyHandler:   handler,
+		ctx:            ctx,
+		cancel:         cancel,
+	}
+}
+
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	update, code, err := h.decode(r)
+	if err != nil {
+		h.handleError(err)
+		http.Error(w, http.StatusText(code), code)
+		return
+	}
+
+	if err := h.acquire(r.Context()); err != nil {
+		if errors.Is(err, ErrWebhookClosed) {
+			h.handleError(err)
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		}
+		return
+	}
+
+	if h.replyHandler != nil {
+		h.serveReply(w, update)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	go func() {
+		defer h.release()
+
+		if err := h.handler.HandleUpdate(h.ctx, update); err != nil {
+			h.handleError(err)
+		}
+	}()
+}
+
+// acquire waits for a slot to handle an update, until the context of the request is done.
+// It returns ErrWebhookClosed once the handler is shut down.
+func (h *WebhookHandler) acquire(ctx context.Context) error {
+	h.once.Do(func() {
+		size := h.MaxConcurrency
+		if size <= 0 {
+			size = DefaultMaxConcurrency
+		}
+		h.slots = make(chan struct{}, size)
+	})
+
+	select {
+	case h.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-h.ctx.Done():
+		return ErrWebhookClosed
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if h.closed {
+		<-h.slots
+		return ErrWebhookClosed
+	}
+	h.running.Add(1)
+	return nil
+}
+
+// release frees the slot of a handled update.
+func (h *WebhookHandler) release() {
+	<-h.slots
+	h.running.Done()
+}
+
+// Shutdown rejects the new requests and waits for the updates being handled until the context is done,
+// then cancels the context of the handlers. It returns the context error when the handlers didn't return in time.
+func (h *WebhookHandler) Shutdown(ctx context.Context) error {
+	h.mu.Lock()
+	h.closed = true
+	h.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		h.running.Wait()
+		close(done)
+	}()
+
+	defer h.cancel()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// serveReply answers Telegram with the Call returned by the ReplyHandler for the update,
+// unless the Call can't be made in the webhook response, then the Call is made by the bot.
+func (h *WebhookHandler) serveReply(w http.ResponseWriter, update Update) {
+	replies := make(chan []byte)
+	answered := make(chan struct{})
+
+	go func() {
+		defer h.release()
+
+		call, err := h.replyHandler.HandleUpdateReply(h.ctx, update)
+		if err != nil {
+			h.handleError(err)
+		}
+
+		var (
+			params *Params
+			reply  []byte
+		)
+		if call != nil {
+			params = resolveParam(call.Params)
+			if h.inResponse(call.Method, params) {
+				if h.bot.chatMigrator != nil {
+					params = h.bot.chatMigrator.rewriteChats(params)
+				}
+				if reply, err = encodeReply(call.Method, params); err != nil {
+					h.handleError(err)
+					call = nil
+				}
+			}
+		}
+
+		select {
+		case replies <- reply:
+			if reply != nil || call == nil {
+				return
+			}
+		case <-answered:
+			if call == nil {
+				return
+			}
+		}
+
+		if _, err := h.bot.makeRequest(h.ctx, call.Method, params); err != nil {
+			h.handleError(err)
+		}
+	}()
+
+	timer := time.NewTimer(h.ReplyTimeout)
+	defer timer.Stop()
+
+	select {
+	case reply := <-replies:
+		if reply == nil {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(reply); err != nil {
+			h.handleError(err)
+		}
+	case <-timer.C:
+		close(answered)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// inResponse reports whether the Call may be made in the webhook response, which bypasses the bot.
+// It may not when the Call uploads a file, or when the bot has interceptors, is a dry run
+// or would delay the Call to keep within its rate limits.
+func (h *WebhookHandler) inResponse(methodName string, params *Params) bool {
+	bot := h.bot
+	if params.hasUpload() || len(bot.interceptors) > 0 || bot.dryRun != nil {
+		return false
+	}
+	return bot.rateLimiter == nil || !bot.rateLimiter.limited(methodName, params)
+}
+
+// encodeReply returns the JSON encoding of a method call made in a webhook response.
+//
+// https://core.telegram.org/bots/api#making-requests-when-getting-updates
+func encodeReply(methodName string, params *Params) ([]byte, error) {
+	object, err := params.jsonObject()
+	if err != nil {
+		return nil, err
+	}
+
+	if object["method"], err = json.Marshal(methodName); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(object)
+}
+
+// decode returns the update of the request,
+// or the status code to reject the request with and the reason.
+func (h *WebhookHandler) decode(r *http.Request) (Update, int, error) {
+	if r.Method != http.MethodPost {
+		return Update{}, http.StatusMethodNotAllowed, fmt.Errorf("telegram: webhook: method %s not allowed", r.Method)
+	}
+
+	if h.secretToken != "" {
+		token := r.Header.Get(SecretTokenHeader)
+		if subtle.ConstantTimeCompare([]byte(token), []byte(h.secretToken)) != 1 {
+			return Update{}, http.StatusUnauthorized, errors.New("telegram: webhook: invalid secret token")
+		}
+	}
+
+	if r.ContentLength > h.MaxBodySize {
+		return Update{}, http.StatusRequestEntityTooLarge, fmt.Errorf("telegram: webhook: body of %d bytes too large", r.ContentLength)
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, h.MaxBodySize+1))
+	if err != nil {
+		return Update{}, http.StatusBadRequest, fmt.Errorf("telegram: webhook: read body: %w", err)
+	}
+	if int64(len(body)) > h.MaxBodySize {
+		return Update{}, http.StatusRequestEntityTooLarge, errors.New("telegram: webhook: body too large")
+	}
+
+	update, err := decodeUpdate(body, keepRaw(h.handler))
+	if err != nil {
+		return Update{}, http.StatusBadRequest, fmt.Errorf("telegram: webhook: decode update: %w", err)
+	}
+
+	if h.bot != nil && h.bot.chatMigrator != nil {
+		h.bot.chatMigrator.observe([]Update{update})
+	}
+
+	return update, http.StatusOK, nil
+}
+
+func (h *WebhookHandler) handleError(err error) {
+	if h.ErrorHandler != nil {
+		h.ErrorHandler(err)
+	}
+}
@@ -0,0 +1,324 @@
+package telegram_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/62bot/telegram"
+	"github.com/billyzaelani/is"
+)
+
+// TestWebhookHandler tests receiving updates sent to a webhook.
+func TestWebhookHandler(t *testing.T) {
+	const (
+		secretToken = "my-secret_token"
+		update      = `{"update_id":1,"message":{"message_id":2,"date":0,"chat":{"id":3,"type":"private"},"text":"hi"}}`
+	)
+
+	tests := map[string]struct {
+		method      string
+		secretToken string
+		body        string
+		wantCode    int
+	}{
+		"ok":                   {method: http.MethodPost, secretToken: secretToken, body: update, wantCode: http.StatusOK},
+		"invalid_secret_token": {method: http.MethodPost, secretToken: "guess", body: update, wantCode: http.StatusUnauthorized},
+		"no_secret_token":      {method: http.MethodPost, body: update, wantCode: http.StatusUnauthorized},
+		"malformed_body":       {method: http.MethodPost, secretToken: secretToken, body: `{"update_id":`, wantCode: http.StatusBadRequest},
+		"oversized_body":       {method: http.MethodPost, secretToken: secretToken, body: `{"update_id":1,"message":{"text":"` + strings.Repeat("a", 1024) + `"}}`, wantCode: http.StatusRequestEntityTooLarge},
+		"method_not_allowed":   {method: http.MethodGet, secretToken: secretToken, wantCode: http.StatusMethodNotAllowed},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			is := is.New(t)
+
+			updates := make(chan telegram.Update, 1)
+			var rejected error
+			h := telegram.NewWebhookHandler(secretToken, telegram.ChannelHandler(updates))
+			h.MaxBodySize = 1024
+			h.ErrorHandler = func(err error) { rejected = err }
+
+			r := httptest.NewRequest(tt.method, "/webhook", strings.NewReader(tt.body))
+			if tt.secretToken != "" {
+				r.Header.Set(telegram.SecretTokenHeader, tt.secretToken)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			is.Equal(w.Code, tt.wantCode)
+			if tt.wantCode != http.StatusOK {
+				is.Error(rejected) // rejected request is reported
+				return
+			}
+
+			select {
+			case got := <-updates:
+				is.Equal(got.UpdateID, 1)
+				is.Equal(got.Message.Text, "hi")
+			case <-time.After(time.Second):
+				t.Fatal("update is not handled")
+			}
+		})
+	}
+}
+
+// TestWebhookHandlerAnswersQuickly tests that a slow handler doesn't delay the response to Telegram.
+func TestWebhookHandlerAnswersQuickly(t *testing.T) {
+	is := is.New(t)
+
+	release := make(chan struct{})
+	defer close(release)
+	h := telegram.NewWebhookHandler("", telegram.UpdateHandlerFunc(func(ctx context.Context, update telegram.Update) error {
+		<-release
+		return nil
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(`{"update_id":1}`)))
+
+	is.Equal(w.Code, http.StatusOK)
+}
+
+// TestWebhookHandlerConcurrency tests that the updates handled at once are bounded,
+// and that Shutdown waits for them before cancelling their context.
+func TestWebhookHandlerConcurrency(t *testing.T) {
+	is := is.New(t)
+
+	var (
+		started = make(chan struct{})
+		release = make(chan struct{})
+		handled = make(chan error, 2)
+	)
+	h := telegram.NewWebhookHandler("", telegram.UpdateHandlerFunc(func(ctx context.Context, update telegram.Update) error {
+		started <- struct{}{}
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+		handled <- ctx.Err()
+		return nil
+	}))
+	h.MaxConcurrency = 1
+
+	serve := func() int {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(`{"update_id":1}`)))
+		return w.Code
+	}
+
+	is.Equal(serve(), http.StatusOK)
+	<-started
+
+	second := make(chan int)
+	go func() { second <- serve() }()
+	select {
+	case <-second:
+		t.Fatal("update handled beyond the maximum concurrency")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	release <- struct{}{}
+	is.NoError(<-handled)
+	is.Equal(<-second, http.StatusOK) // answered once a slot is free
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	is.Error(h.Shutdown(ctx), context.DeadlineExceeded) // the handler doesn't return in time
+	is.Error(<-handled, context.Canceled)               // then its context is cancelled
+
+	is.Equal(serve(), http.StatusServiceUnavailable) // requests are rejected once shut down
+}
+
+// TestWebhookReplyHandler tests replying to webhook updates in the webhook response.
+func TestWebhookReplyHandler(t *testing.T) {
+	const update = `{"update_id":1,"message":{"message_id":2,"date":0,"chat":{"id":12345,"type":"private"},"text":"hi"}}`
+
+	testCases := testFixture.get("sendMessage")
+	tc := testCases.get("ok")
+
+	tests := map[string]struct {
+		reply        func(update telegram.Update) *telegram.Call
+		delay        time.Duration
+		wantBody     string
+		wantRequests int
+	}{
+		"in_response": {
+			reply: func(update telegram.Update) *telegram.Call {
+				return telegram.SendMessageCall(update.Message.Chat.ID, "hello")
+			},
+			wantBody: `{"chat_id":12345,"method":"sendMessage","text":"hello"}`,
+		},
+		"new_call": {
+			reply: func(update telegram.Update) *telegram.Call {
+				return telegram.NewCall("unpinAllChatMessages", telegram.SetChatID(update.Message.Chat.ID))
+			},
+			wantBody: `{"chat_id":12345,"method":"unpinAllChatMessages"}`,
+		},
+		"no_reply": {
+			reply: func(update telegram.Update) *telegram.Call {
+				return nil
+			},
+		},
+		"late_reply": {
+			reply: func(update telegram.Update) *telegram.Call {
+				return telegram.SendMessageCall(update.Message.Chat.ID, "hello")
+			},
+			delay:        50 * time.Millisecond,
+			wantRequests: 1,
+		},
+		"upload": {
+			reply: func(update telegram.Update) *telegram.Call {
+				setDocument := func(params *telegram.Params) {
+					params.SetFile("document", telegram.InputFileReader("note.txt", strings.NewReader("hello")))
+				}
+				return telegram.SendMessageCall(update.Message.Chat.ID, "hello", setDocument)
+			},
+			wantRequests: 1,
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			is := is.New(t)
+
+			requests := make(chan url.Values, 1)
+			client := newTestClient(func(methodName string, params url.Values) *http.Response {
+				requests <- params
+				return newHTTPResponse(tc.StatusCode, tc.Body)
+			})
+			bot, err := telegram.NewBot(validTestToken, telegram.SetClient(client))
+			is.NoError(err)
+
+			h := telegram.NewWebhookReplyHandler(bot, "", telegram.ReplyHandlerFunc(func(ctx context.Context, update telegram.Update) (*telegram.Call, error) {
+				time.Sleep(tt.delay)
+				return tt.reply(update), nil
+			}))
+			h.ReplyTimeout = 10 * time.Millisecond
+			if tt.delay == 0 {
+				h.ReplyTimeout = time.Second
+			}
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(update)))
+
+			is.Equal(w.Code, http.StatusOK)
+			is.Equal(w.Body.String(), tt.wantBody)
+
+			if tt.wantRequests == 0 {
+				return
+			}
+
+			select {
+			case params := <-requests:
+				is.Equal(params.Get("chat_id"), "12345") // reply is made with a regular request
+				is.Equal(params.Get("text"), "hello")
+			case <-time.After(time.Second):
+				t.Fatal("reply is not made")
+			}
+		})
+	}
+}
+
+// TestWebhookChatMigration tests that the migrations announced in the updates of a webhook are remembered.
+func TestWebhookChatMigration(t *testing.T) {
+	is := is.New(t)
+
+	client := newTestClient(func(methodName string, params url.Values) *http.Response {
+		return newHTTPResponse(authorizedCase.StatusCode, authorizedCase.Body)
+	})
+	var migrations [][2]int
+	onMigrate := func(oldChatID, newChatID int) {
+		migrations = append(migrations, [2]int{oldChatID, newChatID})
+	}
+	bot, err := telegram.NewBot(validTestToken, telegram.SetClient(client), telegram.SetChatMigration(onMigrate))
+	is.NoError(err)
+
+	h := telegram.NewWebhookReplyHandler(bot, "", telegram.ReplyHandlerFunc(func(ctx context.Context, update telegram.Update) (*telegram.Call, error) {
+		return nil, nil
+	}))
+
+	update := `{"update_id":1,"message":{"message_id":1,"date":0,"chat":{"id":-1234,"type":"group"},"migrate_to_chat_id":-1001234}}`
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(update)))
+
+	is.Equal(w.Code, http.StatusOK)
+	is.Equal(migrations, [][2]int{{-1234, -1001234}})
+}
+
+// TestWebhookReplyThroughBot tests that a Call in the webhook response goes to the migrated chat,
+// and that a Call is made by the bot when it has interceptors or is a dry run.
+func TestWebhookReplyThroughBot(t *testing.T) {
+	const update = `{"update_id":1,"message":{"message_id":1,"date":0,"chat":{"id":-1234,"type":"group"},"migrate_to_chat_id":-1001234}}`
+
+	var intercepted []string
+	intercept := func(next telegram.Invoker) telegram.Invoker {
+		return func(ctx context.Context, methodName string, params *telegram.Params) (*telegram.Response, error) {
+			intercepted = append(intercepted, methodName+" "+params.Get("chat_id"))
+			return next(ctx, methodName, params)
+		}
+	}
+	var dryRun bytes.Buffer
+
+	tests := map[string]struct {
+		options      []telegram.Option
+		wantBody     string
+		wantRequests []string
+		wantDryRun   string
+	}{
+		"migrated_chat": {
+			wantBody: `{"chat_id":-1001234,"method":"sendMessage","text":"hello"}`,
+		},
+		"interceptors": {
+			options:      []telegram.Option{telegram.SetInterceptors(intercept)},
+			wantRequests: []string{"sendMessage -1001234"},
+		},
+		"dry_run": {
+			options:    []telegram.Option{telegram.SetDryRun(&dryRun)},
+			wantDryRun: `{"chat_id":-1001234,"method":"sendMessage","text":"hello"}` + "\n",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			is := is.New(t)
+			intercepted = nil
+			dryRun.Reset()
+
+			var requests []string
+			client := newTestClient(func(methodName string, params url.Values) *http.Response {
+				requests = append(requests, methodName+" "+params.Get("chat_id"))
+				return newHTTPResponse(http.StatusOK, []byte(`{"ok":true,"result":{"message_id":2,"date":0,"chat":{"id":-1001234,"type":"supergroup"}}}`))
+			})
+			options := append([]telegram.Option{telegram.SetClient(client), telegram.SetLazy(), telegram.SetChatMigration(nil)}, tt.options...)
+			bot, err := telegram.NewBot(validTestToken, options...)
+			is.NoError(err)
+
+			h := telegram.NewWebhookReplyHandler(bot, "", telegram.ReplyHandlerFunc(func(ctx context.Context, update telegram.Update) (*telegram.Call, error) {
+				return telegram.SendMessageCall(update.Message.Chat.ID, "hello"), nil
+			}))
+			h.ErrorHandler = func(err error) { t.Error(err) }
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(update)))
+			is.NoError(h.Shutdown(context.Background())) // waits for the Call made by the bot
+
+			is.Equal(w.Code, http.StatusOK)
+			is.Equal(w.Body.String(), tt.wantBody)
+			is.Equal(requests, tt.wantRequests)
+			if tt.wantRequests != nil {
+				is.Equal(intercepted, []string{"sendMessage -1234"}) // the interceptors run before the chat is rewritten
+			}
+			is.Equal(dryRun.String(), tt.wantDryRun)
+		})
+	}
+}
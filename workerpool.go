@@ -0,0 +1,212 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Default sizes of a WorkerPool.
+const (
+	DefaultWorkers   = 16
+	DefaultQueueSize = 64
+)
+
+// ErrWorkerPoolClosed is returned by HandleUpdate of a closed WorkerPool.
+var ErrWorkerPoolClosed = errors.New("telegram: worker pool closed")
+
+// WorkerPool is an UpdateHandler handling updates concurrently while keeping the order of the updates of a chat.
+// Updates are sharded by chat id, or by user id for inline queries, callback queries and the updates without a chat,
+// and each shard is handled by its own worker, one update at a time.
+// So a slow chat only delays the chats of its shard.
+//
+// Each worker has a bounded queue. HandleUpdate blocks while the queue of the shard is full,
+// which holds back a Poller until the workers catch up.
+// A Poller queues the updates with HandleUpdateAsync and commits the offset of an update only
+// once it's handled successfully, like it does with any other handler.
+//
+//	pool := telegram.NewWorkerPool(dispatcher, 0, 0)
+//	defer pool.Close()
+//
+//	err := telegram.NewPoller(bot).Run(ctx, pool)
+type WorkerPool struct {
+	ErrorHandler func(ctx context.Context, update Update, err error) // Called with the errors of the handler, if not nil.
+
+	handler UpdateHandler
+	queues  []chan poolJob
+	wg      sync.WaitGroup
+
+	mu     sync.RWMutex // guards closed against sending to the queues.
+	closed bool
+
+	statsMu sync.Mutex
+	stats   WorkerPoolStats
+}
+
+// poolJob is an update waiting in the queue of a worker.
+type poolJob struct {
+	ctx      context.Context
+	update   Update
+	queuedAt time.Time
+	done     chan<- error // Receives the error of the handler, if not nil.
+}
+
+// WorkerPoolStats are the statistics of a WorkerPool, for monitoring.
+type WorkerPoolStats struct {
+	Queued     int           // Updates waiting in the queues.
+	Processed  int           // Updates handled since the start, including the failed ones.
+	Failed     int           // Updates whose handler returned an error.
+	Latency    time.Duration // Total time of the processed updates from HandleUpdate to the end of the handler.
+	MaxLatency time.Duration // Longest time of a processed update from HandleUpdate to the end of the handler.
+}
+
+// AverageLatency returns the average time of a processed update from HandleUpdate to the end of the handler.
+func (s WorkerPoolStats) AverageLatency() time.Duration {
+	if s.Processed == 0 {
+		return 0
+	}
+	return s.Latency / time.Duration(s.Processed)
+}
+
+// NewWorkerPool returns a WorkerPool calling the handler with workers workers,
+// each one with a queue of queueSize updates. A size of 0 or less is replaced by its default.
+// The workers are started right away and stopped by Close.
+func NewWorkerPool(handler UpdateHandler, workers, queueSize int) *WorkerPool {
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	if queueSize <= 0 {
+		queueSize = DefaultQueueSize
+	}
+
+	p := &WorkerPool{
+		handler: handler,
+		queues:  make([]chan poolJob, workers),
+	}
+	for i := range p.queues {
+		p.queues[i] = make(chan poolJob, queueSize)
+		p.wg.Add(1)
+		go p.work(p.queues[i])
+	}
+	return p
+}
+
+// HandleUpdate queues the update to the worker of its shard, and returns once the update is queued,
+// before it's handled. It blocks while the queue is full, until the context is done.
+// The handler is called with the context given to HandleUpdate.
+func (p *WorkerPool) HandleUpdate(ctx context.Context, update Update) error {
+	return p.queue(ctx, poolJob{ctx: ctx, update: update, queuedAt: time.Now()})
+}
+
+// HandleUpdateAsync is like HandleUpdate but also returns a channel receiving the error
+// of the handler once the update is handled, nil on success.
+// The channel is buffered, it doesn't need to be received from.
+func (p *WorkerPool) HandleUpdateAsync(ctx context.Context, update Update) (<-chan error, error) {
+	done := make(chan error, 1)
+	if err := p.queue(ctx, poolJob{ctx: ctx, update: update, queuedAt: time.Now(), done: done}); err != nil {
+		return nil, err
+	}
+	return done, nil
+}
+
+// queue sends the job to the worker of the shard of its update.
+func (p *WorkerPool) queue(ctx context.Context, job poolJob) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return ErrWorkerPoolClosed
+	}
+
+	queue := p.queues[shardKey(job.update)%uint64(len(p.queues))]
+	select {
+	case queue <- job:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// keepsRawUpdates reports whether the handler of the pool needs the updates as received, see Update.Raw.
+func (p *WorkerPool) keepsRawUpdates() bool {
+	return keepRaw(p.handler)
+}
+
+// Close stops the workers once the queued updates are handled, and waits for them.
+// HandleUpdate returns ErrWorkerPoolClosed afterward.
+func (p *WorkerPool) Close() {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		for _, queue := range p.queues {
+			close(queue)
+		}
+	}
+	p.mu.Unlock()
+
+	p.wg.Wait()
+}
+
+// Stats returns the current statistics of the pool.
+func (p *WorkerPool) Stats() WorkerPoolStats {
+	p.statsMu.Lock()
+	stats := p.stats
+	p.statsMu.Unlock()
+
+	for _, queue := range p.queues {
+		stats.Queued += len(queue)
+	}
+	return stats
+}
+
+// work handles the updates of a queue until it's closed.
+func (p *WorkerPool) work(queue <-chan poolJob) {
+	defer p.wg.Done()
+
+	for job := range queue {
+		err := p.handler.HandleUpdate(job.ctx, job.update)
+		p.record(time.Since(job.queuedAt), err)
+
+		if err != nil && p.ErrorHandler != nil {
+			p.ErrorHandler(job.ctx, job.update, err)
+		}
+		if job.done != nil {
+			job.done <- err
+		}
+	}
+}
+
+// record adds a processed update to the statistics.
+func (p *WorkerPool) record(latency time.Duration, err error) {
+	p.statsMu.Lock()
+	defer p.statsMu.Unlock()
+
+	p.stats.Processed++
+	if err != nil {
+		p.stats.Failed++
+	}
+	p.stats.Latency += latency
+	if latency > p.stats.MaxLatency {
+		p.stats.MaxLatency = latency
+	}
+}
+
+// shardKey returns the key of the shard of an update: the user id for inline queries and callback queries,
+// the chat id for other updates, the user id for updates without a chat,
+// or the update id for updates without both, such as a poll state.
+func shardKey(update Update) uint64 {
+	switch {
+	case update.InlineQuery != nil, update.ChosenInlineResult != nil, update.CallbackQuery != nil:
+		if user := update.Sender(); user != nil {
+			return uint64(user.ID)
+		}
+	}
+	if chat := update.Chat(); chat != nil {
+		return uint64(chat.ID)
+	}
+	if user := update.Sender(); user != nil {
+		return uint64(user.ID)
+	}
+	return uint64(update.UpdateID)
+}
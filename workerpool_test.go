@@ -0,0 +1,133 @@
+package telegram_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/62bot/telegram"
+	"github.com/billyzaelani/is"
+)
+
+func chatUpdate(updateID, chatID int) telegram.Update {
+	return telegram.Update{
+		UpdateID: updateID,
+		Message:  &telegram.Message{MessageID: updateID, Chat: &telegram.Chat{ID: chatID}},
+	}
+}
+
+// TestWorkerPool tests handling updates of a chat in order, and of other chats concurrently.
+func TestWorkerPool(t *testing.T) {
+	is := is.New(t)
+
+	const (
+		chats          = 4
+		updatesPerChat = 50
+	)
+
+	var (
+		mu      sync.Mutex
+		handled = make(map[int][]int)
+		failed  = 0
+		errFail = errors.New("fail")
+	)
+	handler := telegram.UpdateHandlerFunc(func(ctx context.Context, update telegram.Update) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		chatID := update.Chat().ID
+		handled[chatID] = append(handled[chatID], update.UpdateID)
+		if update.UpdateID%10 == 0 {
+			return errFail
+		}
+		return nil
+	})
+
+	pool := telegram.NewWorkerPool(handler, chats, 0)
+	pool.ErrorHandler = func(ctx context.Context, update telegram.Update, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		failed++
+	}
+
+	updateID := 0
+	for i := 0; i < updatesPerChat; i++ {
+		for chatID := 1; chatID <= chats; chatID++ {
+			updateID++
+			is.NoError(pool.HandleUpdate(context.Background(), chatUpdate(updateID, chatID)))
+		}
+	}
+	pool.Close()
+
+	is.Equal(len(handled), chats)
+	for _, ids := range handled {
+		is.Equal(len(ids), updatesPerChat)
+		for i := 1; i < len(ids); i++ {
+			is.True(ids[i-1] < ids[i]) // updates of a chat are handled in order
+		}
+	}
+
+	stats := pool.Stats()
+	is.Equal(stats.Processed, chats*updatesPerChat)
+	is.Equal(stats.Failed, chats*updatesPerChat/10)
+	is.Equal(failed, stats.Failed)
+	is.Equal(stats.Queued, 0)
+
+	is.Error(pool.HandleUpdate(context.Background(), chatUpdate(0, 1)), telegram.ErrWorkerPoolClosed)
+}
+
+// TestWorkerPoolBackpressure tests that a slow chat blocks its own shard only,
+// and HandleUpdate blocks once the queue of the shard is full.
+func TestWorkerPoolBackpressure(t *testing.T) {
+	is := is.New(t)
+
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	handledFast := make(chan struct{}, 1)
+	handler := telegram.UpdateHandlerFunc(func(ctx context.Context, update telegram.Update) error {
+		if update.Message != nil && update.Chat().ID == 0 {
+			started <- struct{}{}
+			<-release
+			return nil
+		}
+		handledFast <- struct{}{}
+		return nil
+	})
+
+	pool := telegram.NewWorkerPool(handler, 2, 1)
+	defer pool.Close()
+	defer close(release)
+
+	ctx := context.Background()
+	is.NoError(pool.HandleUpdate(ctx, chatUpdate(1, 0))) // taken by the worker, blocked
+	<-started
+	is.NoError(pool.HandleUpdate(ctx, chatUpdate(2, 0))) // fills the queue of the shard
+
+	is.NoError(pool.HandleUpdate(ctx, chatUpdate(3, 1))) // the other shard isn't blocked
+	select {
+	case <-handledFast:
+	case <-time.After(time.Second):
+		t.Fatal("update of another shard not handled")
+	}
+
+	// a callback query is sharded by its user rather than by the chat of its message.
+	callback := telegram.Update{UpdateID: 4, CallbackQuery: &telegram.CallbackQuery{
+		From:    &telegram.User{ID: 1},
+		Message: &telegram.Message{Chat: &telegram.Chat{ID: 0}},
+	}}
+	is.NoError(pool.HandleUpdate(ctx, callback))
+	select {
+	case <-handledFast:
+	case <-time.After(time.Second):
+		t.Fatal("callback query blocked by the chat of its message")
+	}
+
+	is.Equal(pool.Stats().Queued, 1)
+
+	ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	err := pool.HandleUpdate(ctx, chatUpdate(5, 0))
+	is.Error(err, context.DeadlineExceeded) // queue of the shard is full
+}